@@ -0,0 +1,128 @@
+package streamdeck
+
+import "io"
+
+// An Action handles the events of one of the plugin's actions, as declared in the plugin manifest.
+// It may implement any of the handler interfaces for events that belong to a context, such as
+// KeyDownHandler, WillAppearHandler or DidReceiveSettingsHandler. Events that the action does not
+// handle are passed on to the handlers registered on the Client.
+type Action interface{}
+
+// An ActionFactory creates a new instance of an Action for a context that is about to appear.
+type ActionFactory func(e *WillAppearEvent) Action
+
+type actionInstance struct {
+	device string
+	action Action
+}
+
+// RegisterAction registers an Action for the action with the given UUID, replacing any action
+// previously registered for it.
+func (c *Client) RegisterAction(uuid string, a Action) {
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	c.actions[uuid] = a
+}
+
+// RegisterActionFactory registers an ActionFactory for the action with the given UUID. Each
+// context of the action is given its own instance, created when the context appears, which
+// receives every event for that context including the WillAppearEvent itself. The instance is
+// disposed of when the context disappears or its device is disconnected, at which point it is
+// closed if it implements io.Closer.
+//
+// Instances take precedence over an Action registered for the same UUID with RegisterAction.
+func (c *Client) RegisterActionFactory(uuid string, f ActionFactory) {
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	c.factories[uuid] = f
+}
+
+// UnregisterAction removes the Action and ActionFactory registered for the action with the given
+// UUID. Existing instances remain until their contexts disappear.
+func (c *Client) UnregisterAction(uuid string) {
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	delete(c.actions, uuid)
+	delete(c.factories, uuid)
+}
+
+// Instance returns the Action instance for a context, or nil if it has none.
+func (c *Client) Instance(context string) Action {
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	if i, ok := c.instances[context]; ok {
+		return i.action
+	}
+	return nil
+}
+
+func (c *Client) lookupAction(context string, uuid string) Action {
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	if i, ok := c.instances[context]; ok {
+		return i.action
+	}
+	return c.actions[uuid]
+}
+
+func (c *Client) createInstance(uuid string, context string, data []byte) error {
+	c.actionsLock.Lock()
+	f, ok := c.factories[uuid]
+	_, exists := c.instances[context]
+	c.actionsLock.Unlock()
+	if !ok || exists {
+		return nil
+	}
+
+	evt, err := (&WillAppearEvent{}).unmarshal(data)
+	if err != nil {
+		return err
+	}
+	a := f(evt)
+	if a == nil {
+		return nil
+	}
+
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	c.instances[context] = &actionInstance{device: evt.Device, action: a}
+	return nil
+}
+
+func (c *Client) disposeInstance(context string) error {
+	c.actionsLock.Lock()
+	i, ok := c.instances[context]
+	delete(c.instances, context)
+	c.actionsLock.Unlock()
+	if !ok {
+		return nil
+	}
+	return closeAction(i.action)
+}
+
+func (c *Client) disposeDeviceInstances(device string) error {
+	c.actionsLock.Lock()
+	var disposed []Action
+	for context, i := range c.instances {
+		if i.device == device {
+			disposed = append(disposed, i.action)
+			delete(c.instances, context)
+		}
+	}
+	c.actionsLock.Unlock()
+
+	var err error
+	for _, a := range disposed {
+		if cerr := closeAction(a); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func closeAction(a Action) error {
+	if closer, ok := a.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
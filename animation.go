@@ -0,0 +1,282 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	animationTick        = 10 * time.Millisecond
+	defaultAnimationRate = 30
+	defaultGIFDelay      = 100 * time.Millisecond
+)
+
+// A Frame is a single image of an Animation, encoded for SetImage, and how long it is shown for.
+type Frame struct {
+	Image    string
+	Duration time.Duration
+}
+
+// An Animation is a sequence of frames shown in turn on a key.
+type Animation struct {
+	Frames []Frame
+
+	// Loops is the number of times the animation is played, or zero to play it until stopped.
+	Loops int
+}
+
+// NewAnimation returns an Animation of images shown at the given frame rate, in frames per
+// second, encoded with EncodeImage.
+func NewAnimation(images []image.Image, fps float64) (*Animation, error) {
+	if fps <= 0 {
+		return nil, fmt.Errorf("invalid frame rate: %v", fps)
+	}
+	d := time.Duration(float64(time.Second) / fps)
+	a := &Animation{}
+	for _, img := range images {
+		data, err := EncodeImage(img)
+		if err != nil {
+			return nil, err
+		}
+		a.Frames = append(a.Frames, Frame{Image: data, Duration: d})
+	}
+	return a, nil
+}
+
+// NewAnimationFromGIF returns an Animation of the frames of an animated GIF, with their delays
+// and loop count.
+func NewAnimationFromGIF(g *gif.GIF) (*Animation, error) {
+	a := &Animation{}
+	switch {
+	case g.LoopCount < 0:
+		a.Loops = 1
+	case g.LoopCount > 0:
+		a.Loops = g.LoopCount + 1
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() && len(g.Image) > 0 {
+		bounds = g.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		disposal := byte(0)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			draw.Draw(previous, bounds, canvas, bounds.Min, draw.Src)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		data, err := EncodeImage(canvas)
+		if err != nil {
+			return nil, err
+		}
+		d := defaultGIFDelay
+		if i < len(g.Delay) && g.Delay[i] > 0 {
+			d = time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+		a.Frames = append(a.Frames, Frame{Image: data, Duration: d})
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return a, nil
+}
+
+// playback is the state of an animation playing on a context. Its frames are sent with lock held
+// and only until it is stopped, so that no frame is sent once StopAnimation has returned.
+type playback struct {
+	context   string
+	animation *Animation
+	target    Target
+	frame     int
+	loop      int
+	next      time.Time
+
+	lock    sync.Mutex
+	stopped bool
+}
+
+func (p *playback) stop() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.stopped = true
+}
+
+// animator schedules the frames of every animation on a single ticker, limiting the total rate
+// at which frames are sent.
+type animator struct {
+	lock     sync.Mutex
+	playing  map[string]*playback
+	running  bool
+	rate     float64
+	tokens   float64
+	lastTick time.Time
+}
+
+// Animate plays an animation on a context, replacing any animation already playing on it. The
+// animation stops when it has played the number of times it loops, when StopAnimation is called,
+// when the context disappears or when the client stops.
+//
+// Frames of all animations are sent from a single ticker, at no more than the rate set with
+// SetAnimationRate; frames that would exceed it are delayed.
+func (c *Client) Animate(context string, a *Animation, target Target) {
+	c.StopAnimation(context)
+	if len(a.Frames) == 0 {
+		return
+	}
+
+	c.animator.lock.Lock()
+	defer c.animator.lock.Unlock()
+	if c.animator.playing == nil {
+		c.animator.playing = make(map[string]*playback)
+	}
+	c.animator.playing[context] = &playback{
+		context:   context,
+		animation: a,
+		target:    target,
+		next:      time.Now(),
+	}
+	if !c.animator.running {
+		c.animator.running = true
+		go c.runAnimations()
+	}
+}
+
+// StopAnimation stops any animation playing on a context, leaving its current frame displayed.
+func (c *Client) StopAnimation(context string) {
+	c.animator.lock.Lock()
+	p, ok := c.animator.playing[context]
+	delete(c.animator.playing, context)
+	c.animator.lock.Unlock()
+	if ok {
+		p.stop()
+	}
+}
+
+// stopAnimations stops every animation, which also stops the ticker, once the client stops.
+func (c *Client) stopAnimations() {
+	c.animator.lock.Lock()
+	playing := c.animator.playing
+	c.animator.playing = nil
+	c.animator.lock.Unlock()
+	for _, p := range playing {
+		p.stop()
+	}
+}
+
+// SetAnimationRate sets the maximum number of frames sent per second across all animations. The
+// default is 30.
+func (c *Client) SetAnimationRate(framesPerSecond float64) {
+	c.animator.lock.Lock()
+	defer c.animator.lock.Unlock()
+	c.animator.rate = framesPerSecond
+}
+
+func (c *Client) runAnimations() {
+	ticker := time.NewTicker(animationTick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		due, ok := c.animator.due(now)
+		if !ok {
+			return
+		}
+		for _, f := range due {
+			if err := c.sendFrame(f); err != nil {
+				c.reportError(err)
+			}
+		}
+	}
+}
+
+// sendFrame sends a frame unless its animation has been stopped since it became due.
+func (c *Client) sendFrame(f dueFrame) error {
+	f.playback.lock.Lock()
+	defer f.playback.lock.Unlock()
+	if f.playback.stopped {
+		return nil
+	}
+	return c.SetImage(f.playback.context, f.image, f.playback.target)
+}
+
+type dueFrame struct {
+	playback *playback
+	image    string
+}
+
+// due returns the frames to send now and advances their animations. It returns false, and
+// marks the ticker as stopped, once no animations are playing.
+func (a *animator) due(now time.Time) ([]dueFrame, bool) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if len(a.playing) == 0 {
+		a.running = false
+		a.lastTick = time.Time{}
+		return nil, false
+	}
+
+	rate := a.rate
+	if rate <= 0 {
+		rate = defaultAnimationRate
+	}
+	burst := rate * animationTick.Seconds()
+	if burst < 1 {
+		burst = 1
+	}
+	if !a.lastTick.IsZero() {
+		a.tokens += now.Sub(a.lastTick).Seconds() * rate
+	} else {
+		a.tokens = burst
+	}
+	if a.tokens > burst {
+		a.tokens = burst
+	}
+	a.lastTick = now
+
+	var ready []*playback
+	for _, p := range a.playing {
+		if !p.next.After(now) {
+			ready = append(ready, p)
+		}
+	}
+	sort.Slice(ready, func(i int, j int) bool { return ready[i].next.Before(ready[j].next) })
+
+	var frames []dueFrame
+	for _, p := range ready {
+		if a.tokens < 1 {
+			break
+		}
+		a.tokens--
+
+		f := p.animation.Frames[p.frame]
+		frames = append(frames, dueFrame{playback: p, image: f.Image})
+
+		p.next = p.next.Add(f.Duration)
+		if p.next.Before(now) {
+			// Skip ahead rather than sending a burst of late frames.
+			p.next = now.Add(f.Duration)
+		}
+		p.frame++
+		if p.frame == len(p.animation.Frames) {
+			p.frame = 0
+			p.loop++
+			if p.animation.Loops > 0 && p.loop >= p.animation.Loops {
+				delete(a.playing, p.context)
+			}
+		}
+	}
+	return frames, true
+}
@@ -0,0 +1,183 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+func testAnimation(frames int, d time.Duration, loops int) *Animation {
+	a := &Animation{Loops: loops}
+	for i := 0; i < frames; i++ {
+		a.Frames = append(a.Frames, Frame{Image: fmt.Sprint("frame", i), Duration: d})
+	}
+	return a
+}
+
+// animating reports whether the animation ticker is running.
+func animating(c *Client) bool {
+	c.animator.lock.Lock()
+	defer c.animator.lock.Unlock()
+	return c.animator.running
+}
+
+func TestAnimationRate(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetAnimationRate(20)
+	for i := 0; i < 5; i++ {
+		c.Animate(fmt.Sprint("ctx", i), testAnimation(10, time.Millisecond, 0), TargetBoth)
+	}
+	time.Sleep(500 * time.Millisecond)
+	c.stopAnimations()
+
+	// 20 frames per second for half a second, plus the initial burst.
+	if sent := len(conn.take()); sent < 5 || sent > 12 {
+		t.Errorf("sent %d frames in 500ms, want between 5 and 12 at 20 frames per second", sent)
+	}
+}
+
+func TestAnimationLoops(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.Animate("ctx", testAnimation(3, 10*time.Millisecond, 1), TargetBoth)
+	time.Sleep(150 * time.Millisecond)
+
+	var images []string
+	for _, msg := range conn.take() {
+		images = append(images, gjson.Get(msg, "payload.image").String())
+	}
+	if want := []string{"frame0", "frame1", "frame2"}; fmt.Sprint(images) != fmt.Sprint(want) {
+		t.Errorf("sent frames %q, want %q", images, want)
+	}
+	if animating(c) {
+		t.Error("ticker still running after the animation finished")
+	}
+}
+
+func TestStopAnimation(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.Animate("ctx", testAnimation(2, time.Millisecond, 0), TargetBoth)
+	time.Sleep(50 * time.Millisecond)
+	c.StopAnimation("ctx")
+	conn.take()
+
+	time.Sleep(50 * time.Millisecond)
+	if sent := len(conn.take()); sent != 0 {
+		t.Errorf("sent %d frames after stopping the animation", sent)
+	}
+	if animating(c) {
+		t.Error("ticker still running after the animation stopped")
+	}
+}
+
+func TestStopClientStopsAnimations(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.Animate("ctx", testAnimation(2, time.Millisecond, 0), TargetBoth)
+	time.Sleep(20 * time.Millisecond)
+	c.Stop()
+	conn.take()
+
+	time.Sleep(50 * time.Millisecond)
+	if sent := len(conn.take()); sent != 0 {
+		t.Errorf("sent %d frames after the client stopped", sent)
+	}
+	if animating(c) {
+		t.Error("ticker still running after the client stopped")
+	}
+}
+
+func TestAnimationSkipsLateFrames(t *testing.T) {
+	now := time.Now()
+	a := &animator{playing: map[string]*playback{
+		"ctx": {context: "ctx", animation: testAnimation(3, 100*time.Millisecond, 0), next: now.Add(-time.Second)},
+	}}
+	frames, _ := a.due(now)
+	if len(frames) != 1 {
+		t.Fatalf("got %d due frames, want 1", len(frames))
+	}
+	if next := a.playing["ctx"].next; !next.Equal(now.Add(100 * time.Millisecond)) {
+		t.Errorf("next frame due %v after now, want 100ms", next.Sub(now))
+	}
+}
+
+func TestNewAnimationFromGIFLoops(t *testing.T) {
+	for _, test := range []struct{ loopCount, want int }{{-1, 1}, {0, 0}, {2, 3}} {
+		g := &gif.GIF{LoopCount: test.loopCount}
+		a, err := NewAnimationFromGIF(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Loops != test.want {
+			t.Errorf("GIF loop count %d: got %d loops, want %d", test.loopCount, a.Loops, test.want)
+		}
+	}
+}
+
+func TestNewAnimationFromGIFDisposal(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	blue := color.RGBA{B: 0xff, A: 0xff}
+	palette := color.Palette{color.Transparent, red, blue}
+	frame := func(r image.Rectangle, c color.Color) *image.Paletted {
+		img := image.NewPaletted(r, palette)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		return img
+	}
+
+	tests := []struct {
+		disposal byte
+		want     color.RGBA
+	}{
+		{disposal: gif.DisposalNone, want: red},
+		{disposal: gif.DisposalBackground, want: color.RGBA{}},
+		{disposal: gif.DisposalPrevious, want: color.RGBA{}},
+	}
+	for _, test := range tests {
+		g := &gif.GIF{
+			Image:    []*image.Paletted{frame(image.Rect(0, 0, 4, 4), red), frame(image.Rect(0, 0, 2, 2), blue)},
+			Delay:    []int{5, 5},
+			Disposal: []byte{test.disposal, gif.DisposalNone},
+			Config:   image.Config{Width: 4, Height: 4},
+		}
+		a, err := NewAnimationFromGIF(g)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := a.Frames[0].Duration; d != 50*time.Millisecond {
+			t.Errorf("frame duration %v, want 50ms", d)
+		}
+
+		img := decodeDataURI(t, a.Frames[1].Image)
+		last := img.Bounds().Max.Sub(image.Pt(1, 1))
+		if got := color.RGBAModel.Convert(img.At(0, 0)); got != blue {
+			t.Errorf("disposal %d: top left of second frame is %v, want %v", test.disposal, got, blue)
+		}
+		if got := color.RGBAModel.Convert(img.At(last.X, last.Y)); got != test.want {
+			t.Errorf("disposal %d: bottom right of second frame is %v, want %v", test.disposal, got, test.want)
+		}
+	}
+}
+
+func decodeDataURI(t *testing.T, uri string) image.Image {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return img
+}
@@ -1,36 +1,27 @@
 package streamdeck
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/url"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/tidwall/gjson"
 )
 
-// Stream Deck device types.
 const (
-	StreamDeck     = 0
-	StreamDeckMini = 1
+	defaultShutdownTimeout = 5 * time.Second
+	shutdownPollInterval   = 10 * time.Millisecond
 )
 
-// A Device represents a Stream Deck device.
-type Device struct {
-	ID   string
-	Type int
-	Size *Size
-}
-
-// Size specifies the size of a device in rows and columns.
-type Size struct {
-	Rows    int `json:"rows"`
-	Columns int `json:"columns"`
-}
-
 type clientInfo struct {
 	Application struct {
 		Language string `json:"language"`
@@ -40,6 +31,7 @@ type clientInfo struct {
 
 	Devices []struct {
 		ID   string `json:"id"`
+		Name string `json:"name"`
 		Size struct {
 			Rows    int `json:"rows"`
 			Columns int `json:"columns"`
@@ -54,85 +46,209 @@ type conn interface {
 	Close() error
 }
 
-type config struct {
+// Options specifies the parameters passed to a plugin by the Stream Deck software on launch.
+type Options struct {
 	Info          string
 	Port          int
 	PluginUUID    string
 	RegisterEvent string
 }
 
+// ParseOptions parses Options from command line arguments in the form passed by the Stream Deck
+// software, without touching the global flag set.
+func ParseOptions(args []string) (*Options, error) {
+	opts := &Options{}
+	fs := flag.NewFlagSet("streamdeck", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("parsing options: %v", err)
+	}
+	return opts, nil
+}
+
+func (o *Options) register(fs *flag.FlagSet) {
+	fs.IntVar(&o.Port, "port", 0, "the port to connect to")
+	fs.StringVar(&o.PluginUUID, "pluginUUID", "", "the plugin UUID")
+	fs.StringVar(&o.RegisterEvent, "registerEvent", "", "the plugin register event")
+	fs.StringVar(&o.Info, "info", "", "the plugin info")
+}
+
 // A Client encapsulates communication with the Stream Deck software.
 type Client struct {
-	language string
-	platform string
-	version  string
+	info       string
+	language   string
+	platform   string
+	version    string
+	pluginUUID string
 
 	sendLock sync.Mutex
 
-	devices     map[string]*Device
-	devicesLock sync.Mutex
+	animator animator
+
+	writeCache     *writeCache
+	writeCacheLock sync.Mutex
+
+	handlers        sync.WaitGroup
+	running         atomic.Int64
+	dispatchOptions DispatchOptions
+	shutdownTimeout time.Duration
+
+	devices           map[string]*Device
+	deviceSubscribers map[chan DeviceChange]struct{}
+	devicesLock       sync.Mutex
+
+	placements     map[string]Placement
+	placementsLock sync.Mutex
+
+	actions     map[string]Action
+	factories   map[string]ActionFactory
+	instances   map[string]*actionInstance
+	actionsLock sync.Mutex
+
+	applicationDidLaunchHandler          ApplicationDidLaunchHandler
+	applicationDidTerminateHandler       ApplicationDidTerminateHandler
+	deviceDidChangeHandler               DeviceDidChangeHandler
+	deviceDidConnectHandler              DeviceDidConnectHandler
+	deviceDidDisconnectHandler           DeviceDidDisconnectHandler
+	dialDownHandler                      DialDownHandler
+	dialRotateHandler                    DialRotateHandler
+	dialUpHandler                        DialUpHandler
+	didReceiveGlobalSettingsHandler      DidReceiveGlobalSettingsHandler
+	didReceiveSettingsHandler            DidReceiveSettingsHandler
+	keyDownHandler                       KeyDownHandler
+	keyUpHandler                         KeyUpHandler
+	propertyInspectorDidAppearHandler    PropertyInspectorDidAppearHandler
+	propertyInspectorDidDisappearHandler PropertyInspectorDidDisappearHandler
+	reconnectedHandler                   ReconnectedHandler
+	sendToPluginHandler                  SendToPluginHandler
+	titleParametersDidChangeHandler      TitleParametersDidChangeHandler
+	touchTapHandler                      TouchTapHandler
+	willAppearHandler                    WillAppearHandler
+	willDisappearHandler                 WillDisappearHandler
+
+	errorHandler ErrorHandler
+	crashOnPanic bool
 
-	applicationDidLaunchHandler     ApplicationDidLaunchHandler
-	applicationDidTerminateHandler  ApplicationDidTerminateHandler
-	deviceDidConnectHandler         DeviceDidConnectHandler
-	deviceDidDisconnectHandler      DeviceDidDisconnectHandler
-	keyDownHandler                  KeyDownHandler
-	keyUpHandler                    KeyUpHandler
-	titleParametersDidChangeHandler TitleParametersDidChangeHandler
-	willAppearHandler               WillAppearHandler
-	willDisappearHandler            WillDisappearHandler
+	url           string
+	registerEvent string
+	reconnect     *ReconnectOptions
+	recorder      atomic.Pointer[Recorder]
+	stopped       atomic.Bool
+	stop          chan struct{}
+	stopOnce      sync.Once
 
-	conn conn
+	conn     conn
+	connLock sync.Mutex
 }
 
-// Connect returns a new Client configured via the command line.
+// Connect returns a new Client configured via the command line. The options are registered on and
+// parsed with the global flag set; use ParseOptions and ConnectWithOptions to avoid this.
 func Connect() (*Client, error) {
-	cfg := config{}
-	flag.IntVar(&cfg.Port, "port", 0, "the port to connect to")
-	flag.StringVar(&cfg.PluginUUID, "pluginUUID", "", "the plugin UUID")
-	flag.StringVar(&cfg.RegisterEvent, "registerEvent", "", "the plugin register event")
-	flag.StringVar(&cfg.Info, "info", "", "the plugin info")
+	opts := Options{}
+	opts.register(flag.CommandLine)
 	flag.Parse()
+	return ConnectWithOptions(opts)
+}
+
+// ConnectWithOptions returns a new Client configured with the given options.
+func ConnectWithOptions(opts Options) (*Client, error) {
+	c, err := newClient(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	c.url = (&url.URL{Scheme: "ws", Host: fmt.Sprintf("localhost:%v", opts.Port)}).String()
+	if err := c.dial(); err != nil {
+		return nil, err
+	}
 
+	return c, nil
+}
+
+// newClient returns a new Client configured with the given options, without connecting it.
+func newClient(opts Options) (*Client, error) {
 	info := &clientInfo{}
-	err := json.Unmarshal([]byte(cfg.Info), &info)
+	err := json.Unmarshal([]byte(opts.Info), &info)
 	if err != nil {
 		return nil, err
 	}
 
 	c := &Client{
-		devices:  make(map[string]*Device, 0),
-		language: info.Application.Language,
-		platform: info.Application.Platform,
-		version:  info.Application.Version,
+		devices:    make(map[string]*Device, 0),
+		actions:    make(map[string]Action, 0),
+		factories:  make(map[string]ActionFactory, 0),
+		instances:  make(map[string]*actionInstance, 0),
+		info:       opts.Info,
+		language:   info.Application.Language,
+		platform:   info.Application.Platform,
+		version:    info.Application.Version,
+		pluginUUID: opts.PluginUUID,
+
+		registerEvent:   opts.RegisterEvent,
+		shutdownTimeout: defaultShutdownTimeout,
+		stop:            make(chan struct{}),
 	}
 
 	for _, d := range info.Devices {
-		c.addDevice(d.ID, d.Type, d.Size.Columns, d.Size.Rows)
+		c.addDevice(d.ID, d.Type, d.Name, d.Size.Columns, d.Size.Rows)
 	}
 
-	u := url.URL{Scheme: "ws", Host: fmt.Sprintf("localhost:%v", cfg.Port)}
-	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	return c, nil
+}
+
+func (c *Client) dial() error {
+	ws, _, err := websocket.DefaultDialer.Dial(c.url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("connecting: %v", err)
+		return fmt.Errorf("connecting: %v", err)
+	}
+
+	// Close the previous connection, whose socket stays open after a read error, unless the
+	// client was stopped while dialing.
+	c.connLock.Lock()
+	if c.stopped.Load() {
+		c.connLock.Unlock()
+		ws.Close()
+		return errStopped
+	}
+	if c.conn != nil {
+		c.conn.Close()
 	}
 	c.conn = ws
+	c.connLock.Unlock()
 
-	c.sendRegisterEvent(cfg.RegisterEvent, cfg.PluginUUID)
+	if err := c.sendRegisterEvent(c.registerEvent, c.pluginUUID); err != nil {
+		ws.Close()
+		return err
+	}
+	return nil
+}
 
-	return c, nil
+func (c *Client) getConn() conn {
+	c.connLock.Lock()
+	defer c.connLock.Unlock()
+	return c.conn
 }
 
-func (c *Client) addDevice(ID string, deviceType int, columns int, rows int) {
+func (c *Client) addDevice(ID string, deviceType int, name string, columns int, rows int) {
 	c.devicesLock.Lock()
 	defer c.devicesLock.Unlock()
-	c.devices[ID] = &Device{ID: ID, Size: &Size{Columns: columns, Rows: rows}}
+	d := &Device{ID: ID, Type: deviceType, Name: name, Size: &Size{Columns: columns, Rows: rows}}
+	kind := DeviceAdded
+	if _, ok := c.devices[ID]; ok {
+		kind = DeviceChanged
+	}
+	c.devices[ID] = d
+	c.notifyDevice(kind, d)
 }
 
 func (c *Client) removeDevice(ID string) {
 	c.devicesLock.Lock()
 	defer c.devicesLock.Unlock()
-	delete(c.devices, ID)
+	if d, ok := c.devices[ID]; ok {
+		delete(c.devices, ID)
+		c.notifyDevice(DeviceRemoved, d)
+	}
 }
 
 func (c *Client) sendCommand(cmd interface{}) error {
@@ -146,7 +262,15 @@ func (c *Client) sendCommand(cmd interface{}) error {
 func (c *Client) send(data []byte) error {
 	c.sendLock.Lock()
 	defer c.sendLock.Unlock()
-	return c.conn.WriteMessage(websocket.TextMessage, data)
+	c.record(RecordOut, data)
+	return c.getConn().WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *Client) sendClose() error {
+	c.sendLock.Lock()
+	defer c.sendLock.Unlock()
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	return c.getConn().WriteMessage(websocket.CloseMessage, msg)
 }
 
 func (c *Client) sendRegisterEvent(registerEvent string, pluginUUID string) error {
@@ -154,13 +278,39 @@ func (c *Client) sendRegisterEvent(registerEvent string, pluginUUID string) erro
 	return c.send([]byte(msg))
 }
 
+// safeDispatch dispatches an event, recovering from any panic in its handler unless the client is
+// configured to crash.
+func (c *Client) safeDispatch(data []byte) (err error) {
+	defer c.recoverPanic(gjson.GetBytes(data, "event").String(), data, &err)
+	return c.dispatch(data)
+}
+
+// recoverPanic must be deferred directly. It converts a panic into a PanicError stored in err.
+func (c *Client) recoverPanic(event string, data []byte, err *error) {
+	if c.crashOnPanic {
+		return
+	}
+	if v := recover(); v != nil {
+		*err = &PanicError{
+			Event: event,
+			Data:  data,
+			Value: v,
+			Stack: debug.Stack(),
+		}
+	}
+}
+
 func (c *Client) dispatch(data []byte) error {
-	switch gjson.ParseBytes(data).Get("event").String() {
+	msg := gjson.ParseBytes(data)
+	action := msg.Get("action").String()
+	context := msg.Get("context").String()
+	event := msg.Get("event").String()
+	switch event {
 	case "applicationDidLaunch":
 		if c.applicationDidLaunchHandler != nil {
 			evt, err := (&ApplicationDidLaunchEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
 			c.applicationDidLaunchHandler.ApplicationDidLaunch(evt)
 		}
@@ -168,76 +318,241 @@ func (c *Client) dispatch(data []byte) error {
 		if c.applicationDidTerminateHandler != nil {
 			evt, err := (&ApplicationDidTerminateEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
 			c.applicationDidTerminateHandler.ApplicationDidTerminate(evt)
 		}
+	case "deviceDidChange":
+		evt, err := (&DeviceDidChangeEvent{}).unmarshal(data)
+		if err != nil {
+			return &DecodeError{Event: event, Data: data, Err: err}
+		}
+		c.addDevice(evt.Device, evt.DeviceInfo.Type, evt.DeviceInfo.Name, evt.DeviceInfo.Size.Columns, evt.DeviceInfo.Size.Rows)
+		if c.deviceDidChangeHandler != nil {
+			c.deviceDidChangeHandler.DeviceDidChange(evt)
+		}
 	case "deviceDidConnect":
+		evt, err := (&DeviceDidConnectEvent{}).unmarshal(data)
+		if err != nil {
+			return &DecodeError{Event: event, Data: data, Err: err}
+		}
+		c.addDevice(evt.Device, evt.DeviceInfo.Type, evt.DeviceInfo.Name, evt.DeviceInfo.Size.Columns, evt.DeviceInfo.Size.Rows)
 		if c.deviceDidConnectHandler != nil {
-			evt, err := (&DeviceDidConnectEvent{}).unmarshal(data)
-			if err != nil {
-				return err
-			}
-			c.addDevice(evt.Device, evt.DeviceInfo.Type, evt.DeviceInfo.Size.Columns, evt.DeviceInfo.Size.Rows)
 			c.deviceDidConnectHandler.DeviceDidConnect(evt)
 		}
 	case "deviceDidDisconnect":
+		evt, err := (&DeviceDidDisconnectEvent{}).unmarshal(data)
+		if err != nil {
+			return &DecodeError{Event: event, Data: data, Err: err}
+		}
+		c.removeDevice(evt.Device)
+		c.unplaceDevice(evt.Device)
 		if c.deviceDidDisconnectHandler != nil {
-			evt, err := (&DeviceDidDisconnectEvent{}).unmarshal(data)
+			c.deviceDidDisconnectHandler.DeviceDidDisconnect(evt)
+		}
+		return c.disposeDeviceInstances(evt.Device)
+	case "dialDown":
+		h := c.dialDownHandler
+		if a, ok := c.lookupAction(context, action).(DialDownHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&DialDownEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.removeDevice(evt.Device)
-			c.deviceDidDisconnectHandler.DeviceDidDisconnect(evt)
+			h.DialDown(evt)
+		}
+	case "dialRotate":
+		h := c.dialRotateHandler
+		if a, ok := c.lookupAction(context, action).(DialRotateHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&DialRotateEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.DialRotate(evt)
+		}
+	case "dialUp":
+		h := c.dialUpHandler
+		if a, ok := c.lookupAction(context, action).(DialUpHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&DialUpEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.DialUp(evt)
+		}
+	case "didReceiveGlobalSettings":
+		if c.didReceiveGlobalSettingsHandler != nil {
+			evt, err := (&DidReceiveGlobalSettingsEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			c.didReceiveGlobalSettingsHandler.DidReceiveGlobalSettings(evt)
+		}
+	case "didReceiveSettings":
+		h := c.didReceiveSettingsHandler
+		if a, ok := c.lookupAction(context, action).(DidReceiveSettingsHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&DidReceiveSettingsEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.DidReceiveSettings(evt)
 		}
 	case "keyDown":
-		if c.keyDownHandler != nil {
+		c.invalidateCachedState(context)
+		h := c.keyDownHandler
+		if a, ok := c.lookupAction(context, action).(KeyDownHandler); ok {
+			h = a
+		}
+		if h != nil {
 			evt, err := (&KeyDownEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.keyDownHandler.KeyDown(evt)
+			h.KeyDown(evt)
 		}
 	case "keyUp":
-		if c.keyUpHandler != nil {
+		c.invalidateCachedState(context)
+		h := c.keyUpHandler
+		if a, ok := c.lookupAction(context, action).(KeyUpHandler); ok {
+			h = a
+		}
+		if h != nil {
 			evt, err := (&KeyUpEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.KeyUp(evt)
+		}
+	case "propertyInspectorDidAppear":
+		h := c.propertyInspectorDidAppearHandler
+		if a, ok := c.lookupAction(context, action).(PropertyInspectorDidAppearHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&PropertyInspectorDidAppearEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.PropertyInspectorDidAppear(evt)
+		}
+	case "propertyInspectorDidDisappear":
+		h := c.propertyInspectorDidDisappearHandler
+		if a, ok := c.lookupAction(context, action).(PropertyInspectorDidDisappearHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&PropertyInspectorDidDisappearEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.keyUpHandler.KeyUp(evt)
+			h.PropertyInspectorDidDisappear(evt)
+		}
+	case "sendToPlugin":
+		h := c.sendToPluginHandler
+		if a, ok := c.lookupAction(context, action).(SendToPluginHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&SendToPluginEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.SendToPlugin(evt)
 		}
 	case "titleParametersDidChange":
-		if c.titleParametersDidChangeHandler != nil {
+		c.invalidateCache(context)
+		h := c.titleParametersDidChangeHandler
+		if a, ok := c.lookupAction(context, action).(TitleParametersDidChangeHandler); ok {
+			h = a
+		}
+		if h != nil {
 			evt, err := (&TitleParametersDidChangeEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
+			}
+			h.TitleParametersDidChange(evt)
+		}
+	case "touchTap":
+		h := c.touchTapHandler
+		if a, ok := c.lookupAction(context, action).(TouchTapHandler); ok {
+			h = a
+		}
+		if h != nil {
+			evt, err := (&TouchTapEvent{}).unmarshal(data)
+			if err != nil {
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.titleParametersDidChangeHandler.TitleParametersDidChange(evt)
+			h.TouchTap(evt)
 		}
 	case "willAppear":
-		if c.willAppearHandler != nil {
+		c.invalidateCache(context)
+		c.placeContext(msg)
+		if err := c.createInstance(action, context, data); err != nil {
+			return &DecodeError{Event: event, Data: data, Err: err}
+		}
+		h := c.willAppearHandler
+		if a, ok := c.lookupAction(context, action).(WillAppearHandler); ok {
+			h = a
+		}
+		if h != nil {
 			evt, err := (&WillAppearEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.willAppearHandler.WillAppear(evt)
+			h.WillAppear(evt)
 		}
 	case "willDisappear":
-		if c.willDisappearHandler != nil {
+		// Release the context even if the handler panics.
+		defer c.releaseContext(context)
+		h := c.willDisappearHandler
+		if a, ok := c.lookupAction(context, action).(WillDisappearHandler); ok {
+			h = a
+		}
+		if h != nil {
 			evt, err := (&WillDisappearEvent{}).unmarshal(data)
 			if err != nil {
-				return err
+				return &DecodeError{Event: event, Data: data, Err: err}
 			}
-			c.willDisappearHandler.WillDisappear(evt)
+			h.WillDisappear(evt)
 		}
 	default:
-		log.Println("Unknown event received: ", string(data))
+		return &UnknownEventError{Event: event, Data: data}
 	}
 	return nil
 }
 
+// releaseContext releases everything held for a context that has disappeared.
+func (c *Client) releaseContext(context string) {
+	c.StopAnimation(context)
+	c.discardCache(context)
+	c.unplaceContext(context)
+	if err := c.disposeInstance(context); err != nil {
+		c.reportError(err)
+	}
+}
+
+func (c *Client) reportError(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler.Error(err)
+		return
+	}
+	log.Println(err)
+}
+
 // GetDevice returns the device with the given id.
 func (c *Client) GetDevice(id string) *Device {
+	c.devicesLock.Lock()
+	defer c.devicesLock.Unlock()
 	if d, ok := c.devices[id]; ok {
 		return d
 	}
@@ -279,6 +594,16 @@ func (c *Client) HandleApplicationDidTerminateFunc(f ApplicationDidTerminateHand
 	c.HandleApplicationDidTerminate(f)
 }
 
+// HandleDeviceDidChange registers a handler for DeviceDidChangeEvents.
+func (c *Client) HandleDeviceDidChange(h DeviceDidChangeHandler) {
+	c.deviceDidChangeHandler = h
+}
+
+// HandleDeviceDidChangeFunc registers a handler func for DeviceDidChangeEvents.
+func (c *Client) HandleDeviceDidChangeFunc(f DeviceDidChangeHandlerFunc) {
+	c.HandleDeviceDidChange(f)
+}
+
 // HandleDeviceDidConnect registers a handler for DeviceDidConnectEvents.
 func (c *Client) HandleDeviceDidConnect(h DeviceDidConnectHandler) {
 	c.deviceDidConnectHandler = h
@@ -299,6 +624,67 @@ func (c *Client) HandleDeviceDidDisconnectFunc(f DeviceDidDisconnectHandlerFunc)
 	c.HandleDeviceDidDisconnectFunc(f)
 }
 
+// HandleDialDown registers a handler for DialDownEvents.
+func (c *Client) HandleDialDown(h DialDownHandler) {
+	c.dialDownHandler = h
+}
+
+// HandleDialDownFunc registers a handler func for DialDownEvents.
+func (c *Client) HandleDialDownFunc(f DialDownHandlerFunc) {
+	c.HandleDialDown(f)
+}
+
+// HandleDialRotate registers a handler for DialRotateEvents.
+func (c *Client) HandleDialRotate(h DialRotateHandler) {
+	c.dialRotateHandler = h
+}
+
+// HandleDialRotateFunc registers a handler func for DialRotateEvents.
+func (c *Client) HandleDialRotateFunc(f DialRotateHandlerFunc) {
+	c.HandleDialRotate(f)
+}
+
+// HandleDialUp registers a handler for DialUpEvents.
+func (c *Client) HandleDialUp(h DialUpHandler) {
+	c.dialUpHandler = h
+}
+
+// HandleDialUpFunc registers a handler func for DialUpEvents.
+func (c *Client) HandleDialUpFunc(f DialUpHandlerFunc) {
+	c.HandleDialUp(f)
+}
+
+// HandleDidReceiveGlobalSettings registers a handler for DidReceiveGlobalSettingsEvents.
+func (c *Client) HandleDidReceiveGlobalSettings(h DidReceiveGlobalSettingsHandler) {
+	c.didReceiveGlobalSettingsHandler = h
+}
+
+// HandleDidReceiveGlobalSettingsFunc registers a handler func for DidReceiveGlobalSettingsEvents.
+func (c *Client) HandleDidReceiveGlobalSettingsFunc(f DidReceiveGlobalSettingsHandlerFunc) {
+	c.HandleDidReceiveGlobalSettings(f)
+}
+
+// HandleDidReceiveSettings registers a handler for DidReceiveSettingsEvents.
+func (c *Client) HandleDidReceiveSettings(h DidReceiveSettingsHandler) {
+	c.didReceiveSettingsHandler = h
+}
+
+// HandleDidReceiveSettingsFunc registers a handler func for DidReceiveSettingsEvents.
+func (c *Client) HandleDidReceiveSettingsFunc(f DidReceiveSettingsHandlerFunc) {
+	c.HandleDidReceiveSettings(f)
+}
+
+// HandleError registers a handler for errors that occur while dispatching events, such as
+// DecodeErrors, UnknownEventErrors and PanicErrors. Without one, errors are written to the standard logger.
+func (c *Client) HandleError(h ErrorHandler) {
+	c.errorHandler = h
+}
+
+// HandleErrorFunc registers a handler func for errors that occur while dispatching events.
+func (c *Client) HandleErrorFunc(f ErrorHandlerFunc) {
+	c.HandleError(f)
+}
+
 // HandleKeyDown registers a handler for KeyDownEvents.
 func (c *Client) HandleKeyDown(h KeyDownHandler) {
 	c.keyDownHandler = h
@@ -319,6 +705,46 @@ func (c *Client) HandleKeyUpFunc(f KeyUpHandlerFunc) {
 	c.HandleKeyUp(f)
 }
 
+// HandlePropertyInspectorDidAppear registers a handler for PropertyInspectorDidAppearEvents.
+func (c *Client) HandlePropertyInspectorDidAppear(h PropertyInspectorDidAppearHandler) {
+	c.propertyInspectorDidAppearHandler = h
+}
+
+// HandlePropertyInspectorDidAppearFunc registers a handler func for PropertyInspectorDidAppearEvents.
+func (c *Client) HandlePropertyInspectorDidAppearFunc(f PropertyInspectorDidAppearHandlerFunc) {
+	c.HandlePropertyInspectorDidAppear(f)
+}
+
+// HandlePropertyInspectorDidDisappear registers a handler for PropertyInspectorDidDisappearEvents.
+func (c *Client) HandlePropertyInspectorDidDisappear(h PropertyInspectorDidDisappearHandler) {
+	c.propertyInspectorDidDisappearHandler = h
+}
+
+// HandlePropertyInspectorDidDisappearFunc registers a handler func for PropertyInspectorDidDisappearEvents.
+func (c *Client) HandlePropertyInspectorDidDisappearFunc(f PropertyInspectorDidDisappearHandlerFunc) {
+	c.HandlePropertyInspectorDidDisappear(f)
+}
+
+// HandleReconnected registers a handler for ReconnectedEvents.
+func (c *Client) HandleReconnected(h ReconnectedHandler) {
+	c.reconnectedHandler = h
+}
+
+// HandleReconnectedFunc registers a handler func for ReconnectedEvents.
+func (c *Client) HandleReconnectedFunc(f ReconnectedHandlerFunc) {
+	c.HandleReconnected(f)
+}
+
+// HandleSendToPlugin registers a handler for SendToPluginEvents.
+func (c *Client) HandleSendToPlugin(h SendToPluginHandler) {
+	c.sendToPluginHandler = h
+}
+
+// HandleSendToPluginFunc registers a handler func for SendToPluginEvents.
+func (c *Client) HandleSendToPluginFunc(f SendToPluginHandlerFunc) {
+	c.HandleSendToPlugin(f)
+}
+
 // HandleTitleParametersDidChange registers a handler for TitleParametersDidChangeEvents.
 func (c *Client) HandleTitleParametersDidChange(h TitleParametersDidChangeHandler) {
 	c.titleParametersDidChangeHandler = h
@@ -329,6 +755,16 @@ func (c *Client) HandleTitleParametersDidChangeFunc(f TitleParametersDidChangeHa
 	c.HandleTitleParametersDidChange(f)
 }
 
+// HandleTouchTap registers a handler for TouchTapEvents.
+func (c *Client) HandleTouchTap(h TouchTapHandler) {
+	c.touchTapHandler = h
+}
+
+// HandleTouchTapFunc registers a handler func for TouchTapEvents.
+func (c *Client) HandleTouchTapFunc(f TouchTapHandlerFunc) {
+	c.HandleTouchTap(f)
+}
+
 // HandleWillAppear registers a handler for the "willAppear" event.
 func (c *Client) HandleWillAppear(h WillAppearHandler) {
 	c.willAppearHandler = h
@@ -349,6 +785,24 @@ func (c *Client) HandleWillDisappearFunc(f WillDisappearHandlerFunc) {
 	c.HandleWillDisappear(f)
 }
 
+// GetGlobalSettings requests the plugin's global settings, which are delivered asynchronously as a
+// DidReceiveGlobalSettingsEvent.
+func (c *Client) GetGlobalSettings() error {
+	return c.sendCommand(getGlobalSettingsCommand{
+		Name:    "getGlobalSettings",
+		Context: c.pluginUUID,
+	})
+}
+
+// GetSettings requests the settings for a context, which are delivered asynchronously as a
+// DidReceiveSettingsEvent.
+func (c *Client) GetSettings(context string) error {
+	return c.sendCommand(getSettingsCommand{
+		Name:    "getSettings",
+		Context: context,
+	})
+}
+
 // OpenURL instructs the Stream Deck software to open the specified URL in the default browser.
 func (c *Client) OpenURL(url string) error {
 	return c.sendCommand(openURLCommand{
@@ -367,24 +821,71 @@ func (c *Client) SendToPropertyInspector(context string, action string, data jso
 	})
 }
 
+// SetFeedback updates the touchscreen layout items of an encoder context. The payload is an object
+// keyed by layout item name.
+func (c *Client) SetFeedback(context string, payload json.RawMessage) error {
+	return c.sendCommand(setFeedbackCommand{
+		Name:    "setFeedback",
+		Context: context,
+		Payload: payload,
+	})
+}
+
+// SetFeedbackLayout sets the touchscreen layout of an encoder context, either to one of the
+// built-in layouts or to the path of a custom layout file.
+func (c *Client) SetFeedbackLayout(context string, layout string) error {
+	return c.sendCommand(setFeedbackLayoutCommand{
+		Name:    "setFeedbackLayout",
+		Context: context,
+		Payload: &setFeedbackLayoutPayload{Layout: layout},
+	})
+}
+
+// SetGlobalSettings persists the plugin's global settings, which are shared by all contexts.
+func (c *Client) SetGlobalSettings(settings json.RawMessage) error {
+	return c.sendCommand(setGlobalSettingsCommand{
+		Name:    "setGlobalSettings",
+		Context: c.pluginUUID,
+		Payload: settings,
+	})
+}
+
 // SetImage sets the image for a context.
-func (c *Client) SetImage(context string, image string, target string) error {
-	return c.sendCommand(setImageCommand{
+func (c *Client) SetImage(context string, image string, target Target) error {
+	return c.sendCached("setImage", context, target, -1, setImageCommand{
 		Name:    "setImage",
 		Context: context,
 		Payload: &setImagePayload{Image: image, Target: target},
 	})
 }
 
+// SetImageForState sets the image for one of the states of a context.
+func (c *Client) SetImageForState(context string, image string, target Target, state int) error {
+	return c.sendCached("setImage", context, target, state, setImageCommand{
+		Name:    "setImage",
+		Context: context,
+		Payload: &setImagePayload{Image: image, Target: target, State: &state},
+	})
+}
+
 // SetTitle sets the title for a context.
-func (c *Client) SetTitle(context string, title string, target int) error {
-	return c.sendCommand(setTitleCommand{
+func (c *Client) SetTitle(context string, title string, target Target) error {
+	return c.sendCached("setTitle", context, target, -1, setTitleCommand{
 		Name:    "setTitle",
 		Context: context,
 		Payload: &setTitlePayload{Title: title, Target: target},
 	})
 }
 
+// SetTitleForState sets the title for one of the states of a context.
+func (c *Client) SetTitleForState(context string, title string, target Target, state int) error {
+	return c.sendCached("setTitle", context, target, state, setTitleCommand{
+		Name:    "setTitle",
+		Context: context,
+		Payload: &setTitlePayload{Title: title, Target: target, State: &state},
+	})
+}
+
 // ShowAlert shows a temporary alert for a context.
 func (c *Client) ShowAlert(context string) error {
 	return c.sendCommand(showAlertCommand{
@@ -412,13 +913,23 @@ func (c *Client) SetSettings(context string, settings json.RawMessage) error {
 
 // SetState sets the state for a context.
 func (c *Client) SetState(context string, state int) error {
-	return c.sendCommand(setStateCommand{
+	return c.sendCached("setState", context, TargetBoth, -1, setStateCommand{
 		Name:    "setState",
 		Context: context,
 		Payload: &setStatePayload{State: state},
 	})
 }
 
+// SetTriggerDescription sets the descriptions shown in the Stream Deck application for the
+// interactions of an encoder context. Empty descriptions revert to those in the manifest.
+func (c *Client) SetTriggerDescription(context string, desc TriggerDescription) error {
+	return c.sendCommand(setTriggerDescriptionCommand{
+		Name:    "setTriggerDescription",
+		Context: context,
+		Payload: &desc,
+	})
+}
+
 // SwitchToProfile switches the Stream Deck to the given read-only profile, specified in the
 // plugin's manifest.
 func (c *Client) SwitchToProfile(context string, device string, profile string) error {
@@ -432,22 +943,146 @@ func (c *Client) SwitchToProfile(context string, device string, profile string)
 
 // Run begins the event loop and does not return unless stopped or an error occurs.
 func (c *Client) Run() error {
+	return c.RunContext(context.Background())
+}
+
+// RunContext begins the event loop and does not return until the connection is closed, an error
+// occurs or ctx is done.
+//
+// When ctx is done the connection is closed gracefully, waiting up to the shutdown timeout for
+// the close to be acknowledged or for any handlers still running to return, whichever is first,
+// and ctx.Err() is returned; ErrShutdownTimeout is returned instead if handlers are still running
+// at the timeout. If the Stream Deck software closes the connection
+// normally or Stop is called, nil is returned.
+func (c *Client) RunContext(ctx context.Context) error {
+	defer c.stopAnimations()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- c.serve(ctx)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Ask the Stream Deck software to close the connection, which ends the read loop once the
+	// close frame is echoed back.
+	c.sendClose()
+
+	timer := time.NewTimer(c.shutdownTimeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+	defer c.getConn().Close()
 	for {
-		_, data, err := c.conn.ReadMessage()
-		if err != nil {
+		select {
+		case <-errc:
+			return ctx.Err()
+		case <-ticker.C:
+			// The close frame may never be echoed back, so there is no need to wait for it once
+			// the handlers have returned.
+			if c.running.Load() == 0 {
+				return ctx.Err()
+			}
+		case <-timer.C:
+			if c.running.Load() > 0 {
+				return ErrShutdownTimeout
+			}
+			return ctx.Err()
+		}
+	}
+}
+
+// serve runs the read loop until the connection is closed, reconnecting if configured to.
+func (c *Client) serve(ctx context.Context) error {
+	for {
+		err := c.readLoop()
+		c.handlers.Wait()
+
+		if c.stopped.Load() {
+			return nil
+		}
+		if c.reconnect == nil || ctx.Err() != nil ||
+			websocket.IsCloseError(err, websocket.CloseNormalClosure) {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
 				return err
 			}
-			break
+			return nil
 		}
 
-		c.dispatch(data)
+		attempts, err := c.redial(ctx)
+		if err == errStopped {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		c.resetCache()
+		c.notifyReconnected(attempts)
 	}
+}
 
-	return nil
+// SetShutdownTimeout sets how long RunContext waits for running handlers to return once its
+// context is done. The default is 5 seconds.
+func (c *Client) SetShutdownTimeout(d time.Duration) {
+	c.shutdownTimeout = d
+}
+
+// SetRecoverPanics sets whether panics in handlers are recovered from and reported as PanicErrors,
+// which is the default, or are left to crash the plugin.
+func (c *Client) SetRecoverPanics(enabled bool) {
+	c.crashOnPanic = !enabled
+}
+
+func (c *Client) readLoop() error {
+	pool := c.newWorkerPool()
+	if pool != nil {
+		defer pool.close()
+	}
+
+	conn := c.getConn()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		c.record(RecordIn, data)
+
+		if pool != nil {
+			pool.submit(data)
+			continue
+		}
+		c.startHandler()
+		c.handle(data)
+		c.finishHandler()
+	}
+}
+
+// startHandler and finishHandler track the events being handled, so that serve can wait for them
+// and RunContext can tell whether any are still running.
+func (c *Client) startHandler() {
+	c.handlers.Add(1)
+	c.running.Add(1)
+}
+
+func (c *Client) finishHandler() {
+	c.running.Add(-1)
+	c.handlers.Done()
+}
+
+func (c *Client) handle(data []byte) {
+	if err := c.safeDispatch(data); err != nil {
+		c.reportError(err)
+	}
 }
 
-// Stop terminates the event loop.
+// Stop terminates the event loop, abandoning any attempt to reconnect.
 func (c *Client) Stop() {
-	c.conn.Close()
+	c.stopped.Store(true)
+	c.stopOnce.Do(func() { close(c.stop) })
+	c.stopAnimations()
+	c.getConn().Close()
 }
@@ -0,0 +1,150 @@
+package streamdeck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// silentConn is a conn that delivers its events and then blocks until closed, never echoing a
+// close frame.
+type silentConn struct {
+	lock   sync.Mutex
+	events [][]byte
+	closed chan struct{}
+}
+
+func newSilentConn(events ...string) *silentConn {
+	c := &silentConn{closed: make(chan struct{})}
+	for _, e := range events {
+		c.events = append(c.events, []byte(e))
+	}
+	return c
+}
+
+func (c *silentConn) ReadMessage() (int, []byte, error) {
+	c.lock.Lock()
+	if len(c.events) > 0 {
+		data := c.events[0]
+		c.events = c.events[1:]
+		c.lock.Unlock()
+		return websocket.TextMessage, data, nil
+	}
+	c.lock.Unlock()
+	<-c.closed
+	return 0, nil, io.EOF
+}
+
+func (c *silentConn) WriteMessage(messageType int, data []byte) error {
+	return nil
+}
+
+func (c *silentConn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+type panickingAction struct {
+	closed bool
+}
+
+func (a *panickingAction) WillDisappear(e *WillDisappearEvent) {
+	panic("willDisappear")
+}
+
+func (a *panickingAction) Close() error {
+	a.closed = true
+	return nil
+}
+
+func TestWillDisappearReleasesContextAfterPanic(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.devices["dev"] = &Device{ID: "dev", Size: &Size{Columns: 5, Rows: 3}}
+	var instance *panickingAction
+	c.RegisterActionFactory("action", func(e *WillAppearEvent) Action {
+		instance = &panickingAction{}
+		return instance
+	})
+
+	appear := []byte(`{"event":"willAppear","action":"action","context":"ctx","device":"dev",` +
+		`"payload":{"controller":"Keypad","coordinates":{"column":1,"row":2},"settings":{}}}`)
+	if err := c.safeDispatch(appear); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.PlacementOf("ctx"); !ok {
+		t.Fatal("context not placed after willAppear")
+	}
+
+	disappear := []byte(`{"event":"willDisappear","action":"action","context":"ctx","device":"dev",` +
+		`"payload":{"controller":"Keypad","coordinates":{"column":1,"row":2},"settings":{}}}`)
+	var perr *PanicError
+	if err := c.safeDispatch(disappear); !errors.As(err, &perr) {
+		t.Fatalf("got error %v, want a PanicError", err)
+	}
+	if !instance.closed {
+		t.Error("instance not closed")
+	}
+	if _, ok := c.PlacementOf("ctx"); ok {
+		t.Error("context still placed")
+	}
+	if c.Instance("ctx") != nil {
+		t.Error("instance still registered")
+	}
+}
+
+func TestShutdownWithoutCloseEcho(t *testing.T) {
+	c, _ := newTestClient(t)
+	conn := newSilentConn()
+	c.conn = conn
+	c.SetShutdownTimeout(5 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := c.RunContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	// No handlers are running, so there is nothing to wait for.
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shut down after %v, want no wait for the shutdown timeout", elapsed)
+	}
+	select {
+	case <-conn.closed:
+	default:
+		t.Error("connection not closed")
+	}
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.conn = newSilentConn(`{"event":"keyDown","context":"ctx","payload":{}}`)
+	c.SetShutdownTimeout(50 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	c.HandleKeyDownFunc(func(*KeyDownEvent) {
+		close(started)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- c.RunContext(ctx) }()
+	<-started
+	cancel()
+	if err := <-errc; err != ErrShutdownTimeout {
+		t.Fatalf("got error %v, want %v", err, ErrShutdownTimeout)
+	}
+}
@@ -2,13 +2,27 @@ package streamdeck
 
 import "encoding/json"
 
-// Valid targets for some commands that require it, specifically "setTItle" and "setImage".
+// A Target specifies where a title or image is displayed, for the "setTitle" and "setImage"
+// commands.
+type Target int
+
+// Valid targets for some commands that require it, specifically "setTitle" and "setImage".
 const (
-	TargetBoth     = 0
-	TargetHardware = 1
-	TargetSoftware = 2
+	TargetBoth     Target = 0
+	TargetHardware Target = 1
+	TargetSoftware Target = 2
 )
 
+type getGlobalSettingsCommand struct {
+	Name    string `json:"event"`
+	Context string `json:"context"`
+}
+
+type getSettingsCommand struct {
+	Name    string `json:"event"`
+	Context string `json:"context"`
+}
+
 type openURLPayload struct {
 	URL string `json:"url"`
 }
@@ -25,9 +39,32 @@ type sendToPropertyInspectorCommand struct {
 	Payload json.RawMessage `json:"payload"`
 }
 
+type setFeedbackCommand struct {
+	Name    string          `json:"event"`
+	Context string          `json:"context"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+type setFeedbackLayoutPayload struct {
+	Layout string `json:"layout"`
+}
+
+type setFeedbackLayoutCommand struct {
+	Name    string                    `json:"event"`
+	Context string                    `json:"context"`
+	Payload *setFeedbackLayoutPayload `json:"payload"`
+}
+
+type setGlobalSettingsCommand struct {
+	Name    string          `json:"event"`
+	Context string          `json:"context"`
+	Payload json.RawMessage `json:"payload"`
+}
+
 type setImagePayload struct {
 	Image  string `json:"image"`
-	Target string `json:"target"`
+	Target Target `json:"target"`
+	State  *int   `json:"state,omitempty"`
 }
 
 type setImageCommand struct {
@@ -38,7 +75,8 @@ type setImageCommand struct {
 
 type setTitlePayload struct {
 	Title  string `json:"title"`
-	Target int    `json:"target"`
+	Target Target `json:"target"`
+	State  *int   `json:"state,omitempty"`
 }
 
 type setTitleCommand struct {
@@ -74,6 +112,21 @@ type setStateCommand struct {
 	Payload *setStatePayload `json:"payload"`
 }
 
+// TriggerDescription describes the interactions of an encoder, as shown in the Stream Deck
+// application.
+type TriggerDescription struct {
+	LongTouch string `json:"longTouch,omitempty"`
+	Push      string `json:"push,omitempty"`
+	Rotate    string `json:"rotate,omitempty"`
+	Touch     string `json:"touch,omitempty"`
+}
+
+type setTriggerDescriptionCommand struct {
+	Name    string              `json:"event"`
+	Context string              `json:"context"`
+	Payload *TriggerDescription `json:"payload"`
+}
+
 type switchToProfilePayload struct {
 	Profile string `json:"profile"`
 }
@@ -0,0 +1,177 @@
+package streamdeck
+
+import (
+	"sort"
+	"sync"
+)
+
+// Stream Deck device types.
+const (
+	StreamDeck       = 0
+	StreamDeckMini   = 1
+	StreamDeckXL     = 2
+	StreamDeckMobile = 3
+	CorsairGKeys     = 4
+	StreamDeckPedal  = 5
+	CorsairVoyager   = 6
+	StreamDeckPlus   = 7
+	SCUFController   = 8
+	StreamDeckNeo    = 9
+)
+
+// A Device represents a Stream Deck device.
+type Device struct {
+	ID   string
+	Name string
+	Type int
+	Size *Size
+}
+
+// Size specifies the size of a device in rows and columns.
+type Size struct {
+	Rows    int `json:"rows"`
+	Columns int `json:"columns"`
+}
+
+// Kinds of DeviceChange.
+const (
+	DeviceAdded = iota
+	DeviceRemoved
+	DeviceChanged
+)
+
+// A DeviceChange notifies a subscriber that a device was added, removed or changed.
+type DeviceChange struct {
+	Kind   int
+	Device Device
+}
+
+// capabilities describes the hardware of a type of device.
+type capabilities struct {
+	keys       bool
+	dials      bool
+	touchStrip bool
+	keySize    int
+}
+
+var deviceCapabilities = map[int]capabilities{
+	StreamDeck:       {keys: true, keySize: 72},
+	StreamDeckMini:   {keys: true, keySize: 80},
+	StreamDeckXL:     {keys: true, keySize: 96},
+	StreamDeckMobile: {keys: true, keySize: 72},
+	CorsairGKeys:     {keys: true},
+	StreamDeckPedal:  {keys: true},
+	CorsairVoyager:   {keys: true, keySize: 72},
+	StreamDeckPlus:   {keys: true, dials: true, touchStrip: true, keySize: 120},
+	SCUFController:   {keys: true},
+	StreamDeckNeo:    {keys: true, keySize: 96},
+}
+
+// TypeName returns a human readable name for the type of the device, such as "Stream Deck XL".
+func (d *Device) TypeName() string {
+	switch d.Type {
+	case StreamDeck:
+		return "Stream Deck"
+	case StreamDeckMini:
+		return "Stream Deck Mini"
+	case StreamDeckXL:
+		return "Stream Deck XL"
+	case StreamDeckMobile:
+		return "Stream Deck Mobile"
+	case CorsairGKeys:
+		return "Corsair G Keys"
+	case StreamDeckPedal:
+		return "Stream Deck Pedal"
+	case CorsairVoyager:
+		return "Corsair Voyager"
+	case StreamDeckPlus:
+		return "Stream Deck +"
+	case SCUFController:
+		return "SCUF Controller"
+	case StreamDeckNeo:
+		return "Stream Deck Neo"
+	}
+	return "Unknown"
+}
+
+// HasKeys reports whether the device has keys.
+func (d *Device) HasKeys() bool {
+	return deviceCapabilities[d.Type].keys
+}
+
+// HasDisplayKeys reports whether the keys of the device can display titles and images.
+func (d *Device) HasDisplayKeys() bool {
+	return deviceCapabilities[d.Type].keySize > 0
+}
+
+// HasDials reports whether the device has dials.
+func (d *Device) HasDials() bool {
+	return deviceCapabilities[d.Type].dials
+}
+
+// HasTouchStrip reports whether the device has a touchscreen strip.
+func (d *Device) HasTouchStrip() bool {
+	return deviceCapabilities[d.Type].touchStrip
+}
+
+// KeySize returns the native size in pixels of the square key images of the device, or zero if
+// its keys have no display. The Stream Deck software scales images of other sizes to fit.
+func (d *Device) KeySize() int {
+	return deviceCapabilities[d.Type].keySize
+}
+
+// Devices returns a snapshot of the devices currently connected.
+func (c *Client) Devices() []Device {
+	c.devicesLock.Lock()
+	defer c.devicesLock.Unlock()
+	devices := make([]Device, 0, len(c.devices))
+	for _, d := range c.devices {
+		devices = append(devices, d.copy())
+	}
+	sort.Slice(devices, func(i int, j int) bool { return devices[i].ID < devices[j].ID })
+	return devices
+}
+
+// SubscribeDevices returns a channel that receives a DeviceChange whenever a device is added,
+// removed or changed, and a function that unsubscribes and closes the channel. Changes are
+// dropped if the channel's buffer is full, so subscribers should keep up or use Devices to
+// resynchronise.
+func (c *Client) SubscribeDevices(buffer int) (<-chan DeviceChange, func()) {
+	ch := make(chan DeviceChange, buffer)
+
+	c.devicesLock.Lock()
+	defer c.devicesLock.Unlock()
+	if c.deviceSubscribers == nil {
+		c.deviceSubscribers = make(map[chan DeviceChange]struct{})
+	}
+	c.deviceSubscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			c.devicesLock.Lock()
+			defer c.devicesLock.Unlock()
+			delete(c.deviceSubscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// notifyDevice must be called with devicesLock held.
+func (c *Client) notifyDevice(kind int, d *Device) {
+	for ch := range c.deviceSubscribers {
+		select {
+		case ch <- DeviceChange{Kind: kind, Device: d.copy()}:
+		default:
+		}
+	}
+}
+
+func (d *Device) copy() Device {
+	cp := *d
+	if d.Size != nil {
+		size := *d.Size
+		cp.Size = &size
+	}
+	return cp
+}
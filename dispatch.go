@@ -0,0 +1,93 @@
+package streamdeck
+
+import (
+	"hash/fnv"
+
+	"github.com/tidwall/gjson"
+)
+
+const defaultQueueDepth = 64
+
+// DispatchOptions configures how events are dispatched to handlers.
+type DispatchOptions struct {
+	// Workers is the number of events that may be handled concurrently. Events belonging to the
+	// same context are always handled in the order they were received. When Workers is zero,
+	// events are handled one at a time by the event loop itself.
+	Workers int
+
+	// QueueDepth is the number of events that may be waiting for each worker. The default is 64.
+	QueueDepth int
+
+	// DropWhenFull causes events to be dropped, and reported as DroppedEventErrors, when a
+	// worker's queue is full. By default the event loop blocks until there is room.
+	DropWhenFull bool
+}
+
+// SetDispatchOptions configures how events are dispatched to handlers. It must be called before
+// the event loop is started. With more than one worker, handlers, including the error handler, may
+// be called concurrently.
+func (c *Client) SetDispatchOptions(opts DispatchOptions) {
+	c.dispatchOptions = opts
+}
+
+// A workerPool handles events concurrently, assigning each context to a single worker so that its
+// events are handled in order.
+type workerPool struct {
+	client *Client
+	queues []chan []byte
+	drop   bool
+}
+
+func (c *Client) newWorkerPool() *workerPool {
+	opts := c.dispatchOptions
+	if opts.Workers <= 0 {
+		return nil
+	}
+	if opts.QueueDepth <= 0 {
+		opts.QueueDepth = defaultQueueDepth
+	}
+	p := &workerPool{
+		client: c,
+		queues: make([]chan []byte, opts.Workers),
+		drop:   opts.DropWhenFull,
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan []byte, opts.QueueDepth)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *workerPool) submit(data []byte) {
+	h := fnv.New32a()
+	h.Write([]byte(gjson.GetBytes(data, "context").String()))
+	q := p.queues[h.Sum32()%uint32(len(p.queues))]
+
+	p.client.startHandler()
+	if !p.drop {
+		q <- data
+		return
+	}
+	select {
+	case q <- data:
+	default:
+		p.client.finishHandler()
+		p.client.reportError(&DroppedEventError{
+			Event: gjson.GetBytes(data, "event").String(),
+			Data:  data,
+		})
+	}
+}
+
+func (p *workerPool) work(q chan []byte) {
+	for data := range q {
+		p.client.handle(data)
+		p.client.finishHandler()
+	}
+}
+
+func (p *workerPool) close() {
+	for _, q := range p.queues {
+		close(q)
+	}
+}
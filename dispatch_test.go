@@ -0,0 +1,82 @@
+package streamdeck
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func keyDowns(contexts int, events int) []string {
+	var msgs []string
+	for i := 0; i < events; i++ {
+		msgs = append(msgs, fmt.Sprintf(`{"event":"keyDown","context":"ctx%d","payload":{}}`, i%contexts))
+	}
+	return msgs
+}
+
+// runEvents runs the client until every event has been either handled or dropped.
+func runEvents(t *testing.T, c *Client, events []string, handled *atomic.Int64, dropped *atomic.Int64) {
+	t.Helper()
+	var wg sync.WaitGroup
+	wg.Add(len(events))
+	c.HandleErrorFunc(func(err error) {
+		if _, ok := err.(*DroppedEventError); ok {
+			dropped.Add(1)
+			wg.Done()
+		}
+	})
+	prev := c.keyDownHandler
+	c.HandleKeyDownFunc(func(e *KeyDownEvent) {
+		prev.KeyDown(e)
+		handled.Add(1)
+		wg.Done()
+	})
+
+	c.conn = newSilentConn(events...)
+	errc := make(chan error)
+	go func() { errc <- c.Run() }()
+	wg.Wait()
+	c.Stop()
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDispatchDefaultQueueDepth(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.SetDispatchOptions(DispatchOptions{Workers: 4, DropWhenFull: true})
+	c.HandleKeyDownFunc(func(*KeyDownEvent) {})
+
+	var handled, dropped atomic.Int64
+	runEvents(t, c, keyDowns(8, 100), &handled, &dropped)
+	if handled.Load() != 100 || dropped.Load() != 0 {
+		t.Errorf("handled %d and dropped %d events, want all 100 handled", handled.Load(), dropped.Load())
+	}
+}
+
+func TestDispatchDropsWhenFull(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.SetDispatchOptions(DispatchOptions{Workers: 1, QueueDepth: 2, DropWhenFull: true})
+	release := make(chan struct{})
+	var once sync.Once
+	c.HandleKeyDownFunc(func(*KeyDownEvent) {
+		once.Do(func() { <-release })
+	})
+
+	var handled, dropped atomic.Int64
+	go func() {
+		// Release the blocked handler once the queue has overflowed.
+		for dropped.Load() < 7 {
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+	}()
+	runEvents(t, c, keyDowns(1, 10), &handled, &dropped)
+
+	// One event is being handled or waiting for the worker, two are queued and the rest dropped.
+	if dropped.Load() < 7 || handled.Load()+dropped.Load() != 10 {
+		t.Errorf("handled %d and dropped %d events, want at least 7 of 10 dropped", handled.Load(), dropped.Load())
+	}
+}
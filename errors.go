@@ -0,0 +1,63 @@
+package streamdeck
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrShutdownTimeout is returned by RunContext when handlers are still running once the shutdown
+// timeout has elapsed.
+var ErrShutdownTimeout = errors.New("streamdeck: timed out waiting for handlers to return")
+
+// errStopped is returned internally when the client is stopped while reconnecting.
+var errStopped = errors.New("streamdeck: client stopped")
+
+// A DecodeError is reported when an event received from the Stream Deck software cannot be
+// decoded.
+type DecodeError struct {
+	Event string
+	Data  []byte
+	Err   error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("streamdeck: decoding %q event: %v", e.Event, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// A DroppedEventError is reported when an event is dropped because the dispatch queue is full.
+type DroppedEventError struct {
+	Event string
+	Data  []byte
+}
+
+func (e *DroppedEventError) Error() string {
+	return fmt.Sprintf("streamdeck: dropped %q event: dispatch queue full", e.Event)
+}
+
+// An UnknownEventError is reported when an event received from the Stream Deck software is not
+// recognised.
+type UnknownEventError struct {
+	Event string
+	Data  []byte
+}
+
+func (e *UnknownEventError) Error() string {
+	return fmt.Sprintf("streamdeck: unknown event received: %s", e.Data)
+}
+
+// A PanicError is reported when a handler panics while handling an event.
+type PanicError struct {
+	Event string
+	Data  []byte
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("streamdeck: panic handling %q event: %v\n%s", e.Event, e.Value, e.Stack)
+}
@@ -4,6 +4,18 @@ import (
 	"encoding/json"
 )
 
+// Coordinates identify the position of a key or dial on a device.
+type Coordinates struct {
+	Column int `json:"column"`
+	Row    int `json:"row"`
+}
+
+// Controller types reported by events emitted for a context.
+const (
+	ControllerKeypad  = "Keypad"
+	ControllerEncoder = "Encoder"
+)
+
 // An ApplicationDidLaunchEvent is emitted when an application specified in the plugin manifest's
 // "applicationsToMonitor" configuration has launched.
 type ApplicationDidLaunchEvent struct {
@@ -34,11 +46,33 @@ func (e *ApplicationDidTerminateEvent) unmarshal(data []byte) (*ApplicationDidTe
 	return e, nil
 }
 
+// A DeviceDidChangeEvent is emitted when the name or layout of a Stream Deck device changes, such
+// as when Stream Deck Mobile is rotated.
+type DeviceDidChangeEvent struct {
+	Device     string `json:"device"`
+	DeviceInfo struct {
+		Name string `json:"name"`
+		Type int    `json:"type"`
+		Size struct {
+			Rows    int `json:"rows"`
+			Columns int `json:"columns"`
+		} `json:"size"`
+	} `json:"deviceInfo"`
+}
+
+func (e *DeviceDidChangeEvent) unmarshal(data []byte) (*DeviceDidChangeEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // A DeviceDidConnectEvent is emitted when a Stream Deck device is plugged in to the computer.
 type DeviceDidConnectEvent struct {
 	Device     string `json:"device"`
 	DeviceInfo struct {
-		Type int `json:"type"`
+		Name string `json:"name"`
+		Type int    `json:"type"`
 		Size struct {
 			Rows    int `json:"rows"`
 			Columns int `json:"columns"`
@@ -65,6 +99,103 @@ func (e *DeviceDidDisconnectEvent) unmarshal(data []byte) (*DeviceDidDisconnectE
 	return e, nil
 }
 
+// A DialDownEvent is emitted when a dial on the Stream Deck+ is pressed that is associated with a
+// context belonging to this plugin.
+type DialDownEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+	Payload struct {
+		Controller  string          `json:"controller"`
+		Coordinates Coordinates     `json:"coordinates"`
+		Settings    json.RawMessage `json:"settings"`
+	} `json:"payload"`
+}
+
+func (e *DialDownEvent) unmarshal(data []byte) (*DialDownEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A DialUpEvent is emitted when a previously pressed dial on the Stream Deck+ is released that is
+// associated with a context belonging to this plugin.
+type DialUpEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+	Payload struct {
+		Controller  string          `json:"controller"`
+		Coordinates Coordinates     `json:"coordinates"`
+		Settings    json.RawMessage `json:"settings"`
+	} `json:"payload"`
+}
+
+func (e *DialUpEvent) unmarshal(data []byte) (*DialUpEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A DialRotateEvent is emitted when a dial on the Stream Deck+ is rotated that is associated with a
+// context belonging to this plugin. Ticks is negative for counter-clockwise rotation.
+type DialRotateEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+	Payload struct {
+		Controller  string          `json:"controller"`
+		Coordinates Coordinates     `json:"coordinates"`
+		Pressed     bool            `json:"pressed"`
+		Settings    json.RawMessage `json:"settings"`
+		Ticks       int             `json:"ticks"`
+	} `json:"payload"`
+}
+
+func (e *DialRotateEvent) unmarshal(data []byte) (*DialRotateEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A DidReceiveGlobalSettingsEvent is emitted in response to a "getGlobalSettings" command, or when
+// the property inspector saves the plugin's global settings.
+type DidReceiveGlobalSettingsEvent struct {
+	Payload struct {
+		Settings json.RawMessage `json:"settings"`
+	} `json:"payload"`
+}
+
+func (e *DidReceiveGlobalSettingsEvent) unmarshal(data []byte) (*DidReceiveGlobalSettingsEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A DidReceiveSettingsEvent is emitted in response to a "getSettings" command, or when the property
+// inspector saves the settings of a context.
+type DidReceiveSettingsEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+	Payload struct {
+		Coordinates     Coordinates     `json:"coordinates"`
+		IsInMultiAction bool            `json:"isInMultiAction"`
+		Settings        json.RawMessage `json:"settings"`
+	} `json:"payload"`
+}
+
+func (e *DidReceiveSettingsEvent) unmarshal(data []byte) (*DidReceiveSettingsEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // A KeyDownEvent is emitted when a button on the Stream Deck is pressed that is associated with a
 // context belonging to this plugin.
 type KeyDownEvent struct {
@@ -72,10 +203,7 @@ type KeyDownEvent struct {
 	Context string `json:"context"`
 	Device  string `json:"device"`
 	Payload struct {
-		Coordinates struct {
-			Column int `json:"column"`
-			Row    int `json:"row"`
-		} `json:"coordinates"`
+		Coordinates      Coordinates     `json:"coordinates"`
 		IsInMultiAction  bool            `json:"isInMultiAction"`
 		Settings         json.RawMessage `json:"settings"`
 		State            int             `json:"state"`
@@ -97,10 +225,7 @@ type KeyUpEvent struct {
 	Context string `json:"context"`
 	Device  string `json:"device"`
 	Payload struct {
-		Coordinates struct {
-			Column int `json:"column"`
-			Row    int `json:"row"`
-		} `json:"coordinates"`
+		Coordinates      Coordinates     `json:"coordinates"`
 		IsInMultiAction  bool            `json:"isInMultiAction"`
 		Settings         json.RawMessage `json:"settings"`
 		State            int             `json:"state"`
@@ -115,6 +240,57 @@ func (e *KeyUpEvent) unmarshal(data []byte) (*KeyUpEvent, error) {
 	return e, nil
 }
 
+// A PropertyInspectorDidAppearEvent is emitted when the property inspector of a context is opened
+// in the Stream Deck application.
+type PropertyInspectorDidAppearEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+}
+
+func (e *PropertyInspectorDidAppearEvent) unmarshal(data []byte) (*PropertyInspectorDidAppearEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A PropertyInspectorDidDisappearEvent is emitted when the property inspector of a context is
+// closed in the Stream Deck application.
+type PropertyInspectorDidDisappearEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+}
+
+func (e *PropertyInspectorDidDisappearEvent) unmarshal(data []byte) (*PropertyInspectorDidDisappearEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// A ReconnectedEvent is emitted by the Client itself, rather than the Stream Deck software, once
+// the connection has been re-established and the plugin registered again after it was lost.
+type ReconnectedEvent struct {
+	Attempts int
+}
+
+// A SendToPluginEvent is emitted when the property inspector sends data to the plugin using its
+// "sendToPlugin" event. The payload is passed through untouched.
+type SendToPluginEvent struct {
+	Action  string          `json:"action"`
+	Context string          `json:"context"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+func (e *SendToPluginEvent) unmarshal(data []byte) (*SendToPluginEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // A TitleParametersDidChangeEvent is emitted when the user changes the title parameters of a
 // context in the Stream Deck application.
 type TitleParametersDidChangeEvent struct {
@@ -122,10 +298,7 @@ type TitleParametersDidChangeEvent struct {
 	Context string `json:"context"`
 	Device  string `json:"device"`
 	Payload struct {
-		Coordinates struct {
-			Column int `json:"column"`
-			Row    int `json:"row"`
-		} `json:"coordinates"`
+		Coordinates     Coordinates     `json:"coordinates"`
 		Settings        json.RawMessage `json:"settings"`
 		State           int             `json:"state"`
 		Title           string          `json:"title"`
@@ -148,6 +321,28 @@ func (e *TitleParametersDidChangeEvent) unmarshal(data []byte) (*TitleParameters
 	return e, nil
 }
 
+// A TouchTapEvent is emitted when the touchscreen of the Stream Deck+ is tapped within the area
+// of a context belonging to this plugin. TapPos holds the x and y position of the tap.
+type TouchTapEvent struct {
+	Action  string `json:"action"`
+	Context string `json:"context"`
+	Device  string `json:"device"`
+	Payload struct {
+		Controller  string          `json:"controller"`
+		Coordinates Coordinates     `json:"coordinates"`
+		Hold        bool            `json:"hold"`
+		Settings    json.RawMessage `json:"settings"`
+		TapPos      [2]int          `json:"tapPos"`
+	} `json:"payload"`
+}
+
+func (e *TouchTapEvent) unmarshal(data []byte) (*TouchTapEvent, error) {
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
 // A WillAppearEvent is emitted when a context is about to be displayed, either when the Stream Deck
 // application is started or when the user navigates to a page or profile containing the context.
 type WillAppearEvent struct {
@@ -155,10 +350,8 @@ type WillAppearEvent struct {
 	Context string `json:"context"`
 	Device  string `json:"device"`
 	Payload struct {
-		Coordinates struct {
-			Column int `json:"column"`
-			Row    int `json:"row"`
-		} `json:"coordinates"`
+		Controller      string          `json:"controller"`
+		Coordinates     Coordinates     `json:"coordinates"`
 		IsInMultiAction bool            `json:"isInMultiAction"`
 		Settings        json.RawMessage `json:"settings"`
 		State           int             `json:"state"`
@@ -179,10 +372,8 @@ type WillDisappearEvent struct {
 	Context string `json:"context"`
 	Device  string `json:"device"`
 	Payload struct {
-		Coordinates struct {
-			Column int `json:"column"`
-			Row    int `json:"row"`
-		} `json:"coordinates"`
+		Controller      string          `json:"controller"`
+		Coordinates     Coordinates     `json:"coordinates"`
 		IsInMultiAction bool            `json:"isInMultiAction"`
 		Settings        json.RawMessage `json:"settings"`
 		State           int             `json:"state"`
@@ -0,0 +1,286 @@
+package streamdeck
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	defaultDoubleTapInterval = 300 * time.Millisecond
+	defaultLongPressDuration = 500 * time.Millisecond
+	defaultRepeatInterval    = 100 * time.Millisecond
+)
+
+// GestureOptions configures the thresholds used to recognise gestures.
+type GestureOptions struct {
+	// DoubleTapInterval is the longest time between two taps that counts as a double tap. The
+	// default is 300 milliseconds.
+	DoubleTapInterval time.Duration
+
+	// LongPressDuration is how long a key must be held for a long press, which is also when
+	// holding starts to repeat. The default is 500 milliseconds.
+	LongPressDuration time.Duration
+
+	// RepeatInterval is the time between repeats while a key is held. The default is 100
+	// milliseconds.
+	RepeatInterval time.Duration
+}
+
+// A GestureEvent is emitted when a gesture is recognised on a context.
+type GestureEvent struct {
+	Action      string
+	Context     string
+	Device      string
+	Coordinates Coordinates
+	Settings    json.RawMessage
+
+	// Repeat counts the Hold events emitted during the current press, starting at 1.
+	Repeat int
+}
+
+// Gestures recognises taps, double taps, long presses and holds from the key presses of each
+// context. It handles KeyDownEvents, KeyUpEvents and WillDisappearEvents, so it can be registered
+// as an Action or with the HandleKeyDown, HandleKeyUp and HandleWillDisappear methods of a Client.
+//
+// Only the callbacks that are set are recognised. In particular, when DoubleTap is nil a Tap is
+// emitted as soon as the key is released rather than after the double tap interval. A press that
+// is still held when its context disappears emits nothing further.
+//
+// Callbacks are called from timers as well as from the event loop, so they may run concurrently
+// with handlers. Panics in callbacks are reported to the client's error handler like those in
+// handlers.
+type Gestures struct {
+	Tap       func(*GestureEvent)
+	DoubleTap func(*GestureEvent)
+	LongPress func(*GestureEvent)
+	Hold      func(*GestureEvent)
+
+	client *Client
+	opts   GestureOptions
+
+	lock  sync.Mutex
+	state map[string]*press
+	gen   int
+}
+
+// press tracks the gesture in progress on a context. Timers compare their generation with the
+// current one so that they do nothing once superseded. Generations are unique across contexts and
+// presses, so a timer never matches a press that replaced its own.
+type press struct {
+	evt     GestureEvent
+	pressed bool
+	long    bool
+	timer   *time.Timer
+	gen     int
+
+	tap      *GestureEvent
+	tapTimer *time.Timer
+	tapGen   int
+}
+
+// NewGestures returns a Gestures for the client with the given thresholds.
+func NewGestures(c *Client, opts GestureOptions) *Gestures {
+	if opts.DoubleTapInterval <= 0 {
+		opts.DoubleTapInterval = defaultDoubleTapInterval
+	}
+	if opts.LongPressDuration <= 0 {
+		opts.LongPressDuration = defaultLongPressDuration
+	}
+	if opts.RepeatInterval <= 0 {
+		opts.RepeatInterval = defaultRepeatInterval
+	}
+	return &Gestures{
+		client: c,
+		opts:   opts,
+		state:  make(map[string]*press),
+	}
+}
+
+// KeyDown implements KeyDownHandler.
+func (g *Gestures) KeyDown(e *KeyDownEvent) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	p, ok := g.state[e.Context]
+	if !ok {
+		p = &press{}
+		g.state[e.Context] = p
+	}
+	p.stopTimer()
+	p.gen = g.nextGen()
+	p.pressed = true
+	p.long = false
+	p.evt = GestureEvent{
+		Action:      e.Action,
+		Context:     e.Context,
+		Device:      e.Device,
+		Coordinates: e.Payload.Coordinates,
+		Settings:    e.Payload.Settings,
+	}
+
+	if p.tap != nil {
+		// This press may complete a double tap, so the first tap waits for it to end.
+		p.stopTapTimer()
+		p.tapGen = g.nextGen()
+	}
+
+	if g.LongPress != nil || g.Hold != nil {
+		context, gen := e.Context, p.gen
+		p.timer = time.AfterFunc(g.opts.LongPressDuration, func() { g.longPress(context, gen) })
+	}
+}
+
+// KeyUp implements KeyUpHandler.
+func (g *Gestures) KeyUp(e *KeyUpEvent) {
+	g.lock.Lock()
+	p, ok := g.state[e.Context]
+	if !ok || !p.pressed {
+		g.lock.Unlock()
+		return
+	}
+	p.stopTimer()
+	p.gen = g.nextGen()
+	p.pressed = false
+	if p.long {
+		g.forget(e.Context, p)
+		g.lock.Unlock()
+		return
+	}
+
+	evt := GestureEvent{
+		Action:      e.Action,
+		Context:     e.Context,
+		Device:      e.Device,
+		Coordinates: e.Payload.Coordinates,
+		Settings:    e.Payload.Settings,
+	}
+	switch {
+	case g.DoubleTap == nil:
+		g.forget(e.Context, p)
+		g.lock.Unlock()
+		g.call("tap", g.Tap, &evt)
+	case p.tap != nil:
+		p.tap = nil
+		g.forget(e.Context, p)
+		g.lock.Unlock()
+		g.call("doubleTap", g.DoubleTap, &evt)
+	default:
+		p.tap = &evt
+		p.tapGen = g.nextGen()
+		context, gen := e.Context, p.tapGen
+		p.tapTimer = time.AfterFunc(g.opts.DoubleTapInterval, func() { g.tapTimeout(context, gen) })
+		g.lock.Unlock()
+	}
+}
+
+// WillDisappear implements WillDisappearHandler, cancelling any gesture in progress.
+func (g *Gestures) WillDisappear(e *WillDisappearEvent) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if p, ok := g.state[e.Context]; ok {
+		p.stopTimer()
+		p.stopTapTimer()
+		delete(g.state, e.Context)
+	}
+}
+
+func (g *Gestures) longPress(context string, gen int) {
+	g.lock.Lock()
+	p, ok := g.state[context]
+	if !ok || !p.pressed || p.gen != gen {
+		g.lock.Unlock()
+		return
+	}
+	p.long = true
+
+	// A pending tap followed by a long press is a tap after all.
+	tap := p.tap
+	p.tap = nil
+
+	evt := p.evt
+	if g.Hold != nil {
+		p.evt.Repeat = 1
+		p.timer = time.AfterFunc(g.opts.RepeatInterval, func() { g.repeat(context, gen) })
+	}
+	hold := p.evt
+	g.lock.Unlock()
+
+	if tap != nil {
+		g.call("tap", g.Tap, tap)
+	}
+	g.call("longPress", g.LongPress, &evt)
+	g.call("hold", g.Hold, &hold)
+}
+
+func (g *Gestures) repeat(context string, gen int) {
+	g.lock.Lock()
+	p, ok := g.state[context]
+	if !ok || !p.pressed || p.gen != gen {
+		g.lock.Unlock()
+		return
+	}
+	p.evt.Repeat++
+	p.timer = time.AfterFunc(g.opts.RepeatInterval, func() { g.repeat(context, gen) })
+	evt := p.evt
+	g.lock.Unlock()
+
+	g.call("hold", g.Hold, &evt)
+}
+
+func (g *Gestures) tapTimeout(context string, gen int) {
+	g.lock.Lock()
+	p, ok := g.state[context]
+	if !ok || p.tap == nil || p.tapGen != gen {
+		g.lock.Unlock()
+		return
+	}
+	tap := p.tap
+	p.tap = nil
+	g.forget(context, p)
+	g.lock.Unlock()
+
+	g.call("tap", g.Tap, tap)
+}
+
+// nextGen returns a new timer generation. It must be called with the lock held.
+func (g *Gestures) nextGen() int {
+	g.gen++
+	return g.gen
+}
+
+// forget removes the state of a context once no gesture is in progress. It must be called with
+// the lock held.
+func (g *Gestures) forget(context string, p *press) {
+	if !p.pressed && p.tap == nil {
+		delete(g.state, context)
+	}
+}
+
+func (g *Gestures) call(gesture string, f func(*GestureEvent), evt *GestureEvent) {
+	if f == nil {
+		return
+	}
+	if err := g.safeCall(gesture, f, evt); err != nil {
+		g.client.reportError(err)
+	}
+}
+
+func (g *Gestures) safeCall(gesture string, f func(*GestureEvent), evt *GestureEvent) (err error) {
+	defer g.client.recoverPanic(gesture, nil, &err)
+	f(evt)
+	return nil
+}
+
+func (p *press) stopTimer() {
+	if p.timer != nil {
+		p.timer.Stop()
+		p.timer = nil
+	}
+}
+
+func (p *press) stopTapTimer() {
+	if p.tapTimer != nil {
+		p.tapTimer.Stop()
+		p.tapTimer = nil
+	}
+}
@@ -0,0 +1,134 @@
+package streamdeck
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type gestureLog struct {
+	lock sync.Mutex
+	got  []string
+}
+
+func (l *gestureLog) record(name string) func(*GestureEvent) {
+	return func(*GestureEvent) {
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		l.got = append(l.got, name)
+	}
+}
+
+func (l *gestureLog) take() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	got := l.got
+	l.got = nil
+	return got
+}
+
+func newTestGestures(t *testing.T) (*Gestures, *gestureLog) {
+	c, _ := newTestClient(t)
+	g := NewGestures(c, GestureOptions{
+		DoubleTapInterval: 100 * time.Millisecond,
+		LongPressDuration: 300 * time.Millisecond,
+		RepeatInterval:    100 * time.Millisecond,
+	})
+	l := &gestureLog{}
+	g.Tap = l.record("tap")
+	g.DoubleTap = l.record("doubleTap")
+	g.LongPress = l.record("longPress")
+	g.Hold = l.record("hold")
+	return g, l
+}
+
+func TestGestures(t *testing.T) {
+	down := &KeyDownEvent{Context: "ctx"}
+	up := &KeyUpEvent{Context: "ctx"}
+	press := func(g *Gestures, hold time.Duration) {
+		g.KeyDown(down)
+		time.Sleep(hold)
+		g.KeyUp(up)
+	}
+
+	tests := []struct {
+		name    string
+		gesture func(g *Gestures)
+		want    []string
+	}{
+		{
+			name:    "tap",
+			gesture: func(g *Gestures) { press(g, 0) },
+			want:    []string{"tap"},
+		},
+		{
+			name: "double tap",
+			gesture: func(g *Gestures) {
+				press(g, 0)
+				press(g, 0)
+			},
+			want: []string{"doubleTap"},
+		},
+		{
+			name: "double tap with a slow second press",
+			gesture: func(g *Gestures) {
+				press(g, 0)
+				time.Sleep(50 * time.Millisecond)
+				press(g, 150*time.Millisecond)
+			},
+			want: []string{"doubleTap"},
+		},
+		{
+			name: "two taps",
+			gesture: func(g *Gestures) {
+				press(g, 0)
+				time.Sleep(200 * time.Millisecond)
+				press(g, 0)
+			},
+			want: []string{"tap", "tap"},
+		},
+		{
+			name:    "long press",
+			gesture: func(g *Gestures) { press(g, 550*time.Millisecond) },
+			want:    []string{"longPress", "hold", "hold", "hold"},
+		},
+		{
+			name: "tap then long press",
+			gesture: func(g *Gestures) {
+				press(g, 0)
+				press(g, 350*time.Millisecond)
+			},
+			want: []string{"tap", "longPress", "hold"},
+		},
+		{
+			name: "disappear while held",
+			gesture: func(g *Gestures) {
+				g.KeyDown(down)
+				time.Sleep(100 * time.Millisecond)
+				g.WillDisappear(&WillDisappearEvent{Context: "ctx"})
+				g.KeyDown(down)
+				time.Sleep(100 * time.Millisecond)
+				g.WillDisappear(&WillDisappearEvent{Context: "ctx"})
+			},
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			g, l := newTestGestures(t)
+			test.gesture(g)
+			time.Sleep(200 * time.Millisecond)
+			if got := l.take(); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got gestures %q, want %q", got, test.want)
+			}
+
+			g.lock.Lock()
+			defer g.lock.Unlock()
+			if len(g.state) != 0 {
+				t.Errorf("state held for %d contexts after the gesture", len(g.state))
+			}
+		})
+	}
+}
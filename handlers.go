@@ -26,6 +26,19 @@ func (f ApplicationDidTerminateHandlerFunc) ApplicationDidTerminate(e *Applicati
 	f(e)
 }
 
+// A DeviceDidChangeHandler responds to DeviceDidChangeEvents.
+type DeviceDidChangeHandler interface {
+	DeviceDidChange(*DeviceDidChangeEvent)
+}
+
+// A DeviceDidChangeHandlerFunc responds to DeviceDidChangeEvents.
+type DeviceDidChangeHandlerFunc func(*DeviceDidChangeEvent)
+
+// DeviceDidChange calls f(e).
+func (f DeviceDidChangeHandlerFunc) DeviceDidChange(e *DeviceDidChangeEvent) {
+	f(e)
+}
+
 // An DeviceDidConnectHandler responds to DeviceDidConnectEvents.
 type DeviceDidConnectHandler interface {
 	DeviceDidConnect(*DeviceDidConnectEvent)
@@ -52,6 +65,84 @@ func (f DeviceDidDisconnectHandlerFunc) DeviceDidDisconnect(e *DeviceDidDisconne
 	f(e)
 }
 
+// A DialDownHandler responds to DialDownEvents.
+type DialDownHandler interface {
+	DialDown(*DialDownEvent)
+}
+
+// A DialDownHandlerFunc responds to DialDownEvents.
+type DialDownHandlerFunc func(*DialDownEvent)
+
+// DialDown calls f(e).
+func (f DialDownHandlerFunc) DialDown(e *DialDownEvent) {
+	f(e)
+}
+
+// A DialRotateHandler responds to DialRotateEvents.
+type DialRotateHandler interface {
+	DialRotate(*DialRotateEvent)
+}
+
+// A DialRotateHandlerFunc responds to DialRotateEvents.
+type DialRotateHandlerFunc func(*DialRotateEvent)
+
+// DialRotate calls f(e).
+func (f DialRotateHandlerFunc) DialRotate(e *DialRotateEvent) {
+	f(e)
+}
+
+// A DialUpHandler responds to DialUpEvents.
+type DialUpHandler interface {
+	DialUp(*DialUpEvent)
+}
+
+// A DialUpHandlerFunc responds to DialUpEvents.
+type DialUpHandlerFunc func(*DialUpEvent)
+
+// DialUp calls f(e).
+func (f DialUpHandlerFunc) DialUp(e *DialUpEvent) {
+	f(e)
+}
+
+// A DidReceiveGlobalSettingsHandler responds to DidReceiveGlobalSettingsEvents.
+type DidReceiveGlobalSettingsHandler interface {
+	DidReceiveGlobalSettings(*DidReceiveGlobalSettingsEvent)
+}
+
+// A DidReceiveGlobalSettingsHandlerFunc responds to DidReceiveGlobalSettingsEvents.
+type DidReceiveGlobalSettingsHandlerFunc func(*DidReceiveGlobalSettingsEvent)
+
+// DidReceiveGlobalSettings calls f(e).
+func (f DidReceiveGlobalSettingsHandlerFunc) DidReceiveGlobalSettings(e *DidReceiveGlobalSettingsEvent) {
+	f(e)
+}
+
+// A DidReceiveSettingsHandler responds to DidReceiveSettingsEvents.
+type DidReceiveSettingsHandler interface {
+	DidReceiveSettings(*DidReceiveSettingsEvent)
+}
+
+// A DidReceiveSettingsHandlerFunc responds to DidReceiveSettingsEvents.
+type DidReceiveSettingsHandlerFunc func(*DidReceiveSettingsEvent)
+
+// DidReceiveSettings calls f(e).
+func (f DidReceiveSettingsHandlerFunc) DidReceiveSettings(e *DidReceiveSettingsEvent) {
+	f(e)
+}
+
+// An ErrorHandler responds to errors that occur while dispatching events.
+type ErrorHandler interface {
+	Error(error)
+}
+
+// An ErrorHandlerFunc responds to errors that occur while dispatching events.
+type ErrorHandlerFunc func(error)
+
+// Error calls f(err).
+func (f ErrorHandlerFunc) Error(err error) {
+	f(err)
+}
+
 // An KeyDownHandler resopnds to KeyDownEvents.
 type KeyDownHandler interface {
 	KeyDown(*KeyDownEvent)
@@ -78,6 +169,58 @@ func (f KeyUpHandlerFunc) KeyUp(e *KeyUpEvent) {
 	f(e)
 }
 
+// A PropertyInspectorDidAppearHandler responds to PropertyInspectorDidAppearEvents.
+type PropertyInspectorDidAppearHandler interface {
+	PropertyInspectorDidAppear(*PropertyInspectorDidAppearEvent)
+}
+
+// A PropertyInspectorDidAppearHandlerFunc responds to PropertyInspectorDidAppearEvents.
+type PropertyInspectorDidAppearHandlerFunc func(*PropertyInspectorDidAppearEvent)
+
+// PropertyInspectorDidAppear calls f(e).
+func (f PropertyInspectorDidAppearHandlerFunc) PropertyInspectorDidAppear(e *PropertyInspectorDidAppearEvent) {
+	f(e)
+}
+
+// A PropertyInspectorDidDisappearHandler responds to PropertyInspectorDidDisappearEvents.
+type PropertyInspectorDidDisappearHandler interface {
+	PropertyInspectorDidDisappear(*PropertyInspectorDidDisappearEvent)
+}
+
+// A PropertyInspectorDidDisappearHandlerFunc responds to PropertyInspectorDidDisappearEvents.
+type PropertyInspectorDidDisappearHandlerFunc func(*PropertyInspectorDidDisappearEvent)
+
+// PropertyInspectorDidDisappear calls f(e).
+func (f PropertyInspectorDidDisappearHandlerFunc) PropertyInspectorDidDisappear(e *PropertyInspectorDidDisappearEvent) {
+	f(e)
+}
+
+// A ReconnectedHandler responds to ReconnectedEvents.
+type ReconnectedHandler interface {
+	Reconnected(*ReconnectedEvent)
+}
+
+// A ReconnectedHandlerFunc responds to ReconnectedEvents.
+type ReconnectedHandlerFunc func(*ReconnectedEvent)
+
+// Reconnected calls f(e).
+func (f ReconnectedHandlerFunc) Reconnected(e *ReconnectedEvent) {
+	f(e)
+}
+
+// A SendToPluginHandler responds to SendToPluginEvents.
+type SendToPluginHandler interface {
+	SendToPlugin(*SendToPluginEvent)
+}
+
+// A SendToPluginHandlerFunc responds to SendToPluginEvents.
+type SendToPluginHandlerFunc func(*SendToPluginEvent)
+
+// SendToPlugin calls f(e).
+func (f SendToPluginHandlerFunc) SendToPlugin(e *SendToPluginEvent) {
+	f(e)
+}
+
 // An TitleParametersDidChangeHandler responds to TitleParametersDidChangeEvents.
 type TitleParametersDidChangeHandler interface {
 	TitleParametersDidChange(*TitleParametersDidChangeEvent)
@@ -91,6 +234,19 @@ func (f TitleParametersDidChangeHandlerFunc) TitleParametersDidChange(e *TitlePa
 	f(e)
 }
 
+// A TouchTapHandler responds to TouchTapEvents.
+type TouchTapHandler interface {
+	TouchTap(*TouchTapEvent)
+}
+
+// A TouchTapHandlerFunc responds to TouchTapEvents.
+type TouchTapHandlerFunc func(*TouchTapEvent)
+
+// TouchTap calls f(e).
+func (f TouchTapHandlerFunc) TouchTap(e *TouchTapEvent) {
+	f(e)
+}
+
 // An WillAppearHandler handles an "willAppear" event.
 type WillAppearHandler interface {
 	WillAppear(*WillAppearEvent)
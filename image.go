@@ -0,0 +1,246 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Register the GIF decoder for images loaded from files.
+	_ "image/gif"
+)
+
+// Sizes of key images in pixels. Images are square, and the Stream Deck software accepts both
+// standard and high resolution images.
+const (
+	KeyImageSize   = 72
+	KeyImageSize2x = 144
+)
+
+// EncodeImage encodes an image as a PNG data URI suitable for SetImage. Images that are not a key
+// image size are resized with FitKeyImage.
+func EncodeImage(img image.Image) (string, error) {
+	return EncodeImageForDevice(img, nil)
+}
+
+// EncodeImageForDevice is like EncodeImage but resizes images with FitKeyImageForDevice, keeping
+// images of the native or double key size of the device. A nil device is treated like EncodeImage.
+func EncodeImageForDevice(img image.Image, d *Device) (string, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, FitKeyImageForDevice(img, d)); err != nil {
+		return "", fmt.Errorf("encoding image: %v", err)
+	}
+	return dataURI("image/png", buf.Bytes()), nil
+}
+
+// EncodeJPEG encodes an image as a JPEG data URI suitable for SetImage, resizing it like
+// EncodeImage. Quality ranges from 1 to 100.
+func EncodeJPEG(img image.Image, quality int) (string, error) {
+	return encodeJPEG(img, quality, nil)
+}
+
+func encodeJPEG(img image.Image, quality int, d *Device) (string, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, FitKeyImageForDevice(img, d), &jpeg.Options{Quality: quality}); err != nil {
+		return "", fmt.Errorf("encoding image: %v", err)
+	}
+	return dataURI("image/jpeg", buf.Bytes()), nil
+}
+
+// EncodeSVG encodes an SVG document as a data URI suitable for SetImage.
+func EncodeSVG(svg string) string {
+	return "data:image/svg+xml;charset=utf8," + url.PathEscape(svg)
+}
+
+// EncodeImageFile encodes a PNG, JPEG, GIF or SVG file as a data URI suitable for SetImage.
+// Bitmap images are resized like EncodeImage.
+func EncodeImageFile(path string) (string, error) {
+	return encodeImageFile(path, nil)
+}
+
+func encodeImageFile(path string, d *Device) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading image: %v", err)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return EncodeSVG(string(data)), nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("decoding image: %v", err)
+	}
+	if isKeyImageSize(img.Bounds(), d) && (format == "png" || format == "jpeg") {
+		return dataURI("image/"+format, data), nil
+	}
+	return EncodeImageForDevice(img, d)
+}
+
+func dataURI(mediaType string, data []byte) string {
+	return "data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// isKeyImageSize reports whether b is a square of KeyImageSize or KeyImageSize2x pixels, or of
+// the native or double key size of d if it is not nil.
+func isKeyImageSize(b image.Rectangle, d *Device) bool {
+	if b.Dx() != b.Dy() {
+		return false
+	}
+	if b.Dx() == KeyImageSize || b.Dx() == KeyImageSize2x {
+		return true
+	}
+	if d == nil || d.KeySize() == 0 {
+		return false
+	}
+	return b.Dx() == d.KeySize() || b.Dx() == 2*d.KeySize()
+}
+
+// FitKeyImage returns img unchanged if it is a square of KeyImageSize or KeyImageSize2x pixels.
+// Otherwise it returns a copy scaled to fit within the closer of those sizes, preserving its
+// aspect ratio and centered on a transparent background.
+func FitKeyImage(img image.Image) image.Image {
+	return FitKeyImageForDevice(img, nil)
+}
+
+// FitKeyImageForDevice is like FitKeyImage but also keeps images of the native or double key size
+// of the device, and scales others to the closer of those sizes instead. A nil device, or one
+// whose keys have no display, is treated like FitKeyImage.
+func FitKeyImageForDevice(img image.Image, d *Device) image.Image {
+	b := img.Bounds()
+	if isKeyImageSize(b, d) {
+		return img
+	}
+	native := KeyImageSize
+	if d != nil && d.KeySize() > 0 {
+		native = d.KeySize()
+	}
+	size := 2 * native
+	if b.Dx() <= native && b.Dy() <= native {
+		size = native
+	}
+	return ScaleImage(img, size, size)
+}
+
+// ScaleImage scales img with bilinear interpolation to fit within a new image of width by height
+// pixels, preserving its aspect ratio and centering it on a transparent background.
+func ScaleImage(img image.Image, width int, height int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	sb := img.Bounds()
+	if sb.Empty() {
+		return dst
+	}
+
+	scale := float64(width) / float64(sb.Dx())
+	if s := float64(height) / float64(sb.Dy()); s < scale {
+		scale = s
+	}
+	w := int(float64(sb.Dx())*scale + 0.5)
+	h := int(float64(sb.Dy())*scale + 0.5)
+	r := image.Rect((width-w)/2, (height-h)/2, (width-w)/2+w, (height-h)/2+h)
+
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		sy := (float64(y-r.Min.Y)+0.5)/scale - 0.5
+		for x := r.Min.X; x < r.Max.X; x++ {
+			sx := (float64(x-r.Min.X)+0.5)/scale - 0.5
+			dst.Set(x, y, bilinear(img, sb, sx, sy))
+		}
+	}
+	return dst
+}
+
+func bilinear(img image.Image, b image.Rectangle, x float64, y float64) color.Color {
+	clamp := func(v int, max int) int {
+		if v < 0 {
+			return 0
+		}
+		if v >= max {
+			return max - 1
+		}
+		return v
+	}
+	x0, y0 := int(x), int(y)
+	if x < 0 {
+		x0 = -1
+	}
+	if y < 0 {
+		y0 = -1
+	}
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var sum [4]float64
+	for _, p := range []struct {
+		dx, dy int
+		w      float64
+	}{
+		{0, 0, (1 - fx) * (1 - fy)},
+		{1, 0, fx * (1 - fy)},
+		{0, 1, (1 - fx) * fy},
+		{1, 1, fx * fy},
+	} {
+		px := b.Min.X + clamp(x0+p.dx, b.Dx())
+		py := b.Min.Y + clamp(y0+p.dy, b.Dy())
+		r, g, bl, a := img.At(px, py).RGBA()
+		sum[0] += float64(r) * p.w
+		sum[1] += float64(g) * p.w
+		sum[2] += float64(bl) * p.w
+		sum[3] += float64(a) * p.w
+	}
+	return color.RGBA64{
+		R: uint16(sum[0] + 0.5),
+		G: uint16(sum[1] + 0.5),
+		B: uint16(sum[2] + 0.5),
+		A: uint16(sum[3] + 0.5),
+	}
+}
+
+// SetImageFromImage sets the image for a context from an image, encoded with
+// EncodeImageForDevice for the device the context is on.
+func (c *Client) SetImageFromImage(context string, img image.Image, target Target) error {
+	data, err := EncodeImageForDevice(img, c.contextDevice(context))
+	if err != nil {
+		return err
+	}
+	return c.SetImage(context, data, target)
+}
+
+// SetImageFromJPEG sets the image for a context from an image, encoded with EncodeJPEG but resized
+// for the device the context is on.
+func (c *Client) SetImageFromJPEG(context string, img image.Image, quality int, target Target) error {
+	data, err := encodeJPEG(img, quality, c.contextDevice(context))
+	if err != nil {
+		return err
+	}
+	return c.SetImage(context, data, target)
+}
+
+// SetImageFromSVG sets the image for a context from an SVG document.
+func (c *Client) SetImageFromSVG(context string, svg string, target Target) error {
+	return c.SetImage(context, EncodeSVG(svg), target)
+}
+
+// SetImageFromFile sets the image for a context from a file, encoded with EncodeImageFile but
+// resized for the device the context is on.
+func (c *Client) SetImageFromFile(context string, path string, target Target) error {
+	data, err := encodeImageFile(path, c.contextDevice(context))
+	if err != nil {
+		return err
+	}
+	return c.SetImage(context, data, target)
+}
+
+// contextDevice returns the device a context is on, or nil if it is not known.
+func (c *Client) contextDevice(context string) *Device {
+	p, ok := c.PlacementOf(context)
+	if !ok {
+		return nil
+	}
+	return c.GetDevice(p.Device)
+}
@@ -0,0 +1,67 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestFitKeyImageForDevice(t *testing.T) {
+	plus := &Device{Type: StreamDeckPlus}
+	pedal := &Device{Type: StreamDeckPedal}
+	tests := []struct {
+		size   int
+		device *Device
+		want   int
+	}{
+		{size: 72, device: nil, want: 72},
+		{size: 144, device: nil, want: 144},
+		{size: 240, device: nil, want: 144},
+		{size: 50, device: nil, want: 72},
+		{size: 240, device: plus, want: 240},
+		{size: 120, device: plus, want: 120},
+		{size: 144, device: plus, want: 144},
+		{size: 96, device: plus, want: 120},
+		{size: 192, device: plus, want: 240},
+		{size: 192, device: pedal, want: 144},
+	}
+	for _, test := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, test.size, test.size))
+		got := FitKeyImageForDevice(img, test.device).Bounds()
+		if got.Dx() != test.want || got.Dy() != test.want {
+			t.Errorf("fitting %dpx image for %v: got %v, want %dpx", test.size, test.device, got, test.want)
+		}
+	}
+}
+
+func TestSetImageFromImageFitsContextDevice(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.devices["dev"] = &Device{ID: "dev", Type: StreamDeckPlus, Size: &Size{Columns: 4, Rows: 2}}
+	c.dispatch([]byte(`{"event":"willAppear","action":"action","context":"ctx","device":"dev",` +
+		`"payload":{"controller":"Keypad","coordinates":{"column":0,"row":0},"settings":{}}}`))
+
+	if err := c.SetImageFromImage("ctx", image.NewRGBA(image.Rect(0, 0, 240, 240)), TargetBoth); err != nil {
+		t.Fatal(err)
+	}
+	written := conn.take()
+	if len(written) != 1 {
+		t.Fatalf("sent %d commands, want 1", len(written))
+	}
+	uri := gjson.Get(written[0], "payload.image").String()
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(uri, "data:image/png;base64,"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := png.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 240 || cfg.Height != 240 {
+		t.Errorf("sent %dx%d image, want 240x240", cfg.Width, cfg.Height)
+	}
+}
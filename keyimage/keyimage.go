@@ -0,0 +1,167 @@
+// Package keyimage composes key images from layers, such as a background, an icon, text, a badge
+// and a progress bar, ready to be sent with Client.SetImage.
+package keyimage
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	streamdeck "github.com/cliffrowley/go-streamdeck"
+)
+
+// A Layer draws part of a key image.
+type Layer interface {
+	Draw(dst draw.Image)
+}
+
+// An Image is a key image composed of layers, which are drawn in order.
+type Image struct {
+	Size   int
+	Layers []Layer
+
+	device *streamdeck.Device
+}
+
+// New returns an empty Image of the given size in pixels. A size of zero uses
+// streamdeck.KeyImageSize2x.
+func New(size int) *Image {
+	if size <= 0 {
+		size = streamdeck.KeyImageSize2x
+	}
+	return &Image{Size: size}
+}
+
+// NewForDevice returns an empty Image at twice the native key size of a device, for sharpness on
+// high resolution displays, or of streamdeck.KeyImageSize2x if the device is nil or its keys have
+// no display.
+func NewForDevice(d *streamdeck.Device) *Image {
+	if d == nil {
+		return New(0)
+	}
+	i := New(2 * d.KeySize())
+	i.device = d
+	return i
+}
+
+// Add appends layers to the image and returns it.
+func (i *Image) Add(layers ...Layer) *Image {
+	i.Layers = append(i.Layers, layers...)
+	return i
+}
+
+// Render draws every layer and returns the result.
+func (i *Image) Render() *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, i.Size, i.Size))
+	for _, l := range i.Layers {
+		l.Draw(dst)
+	}
+	return dst
+}
+
+// Encode renders the image and encodes it as a data URI for Client.SetImage, keeping its size if
+// it was made for a device with NewForDevice.
+func (i *Image) Encode() (string, error) {
+	return streamdeck.EncodeImageForDevice(i.Render(), i.device)
+}
+
+// A Background fills the key with a color.
+type Background struct {
+	Color color.Color
+}
+
+// Draw implements Layer.
+func (b Background) Draw(dst draw.Image) {
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(b.Color), image.Point{}, draw.Over)
+}
+
+// A Gradient fills the key with a linear gradient, from top to bottom or, if Horizontal is set,
+// from left to right.
+type Gradient struct {
+	From       color.Color
+	To         color.Color
+	Horizontal bool
+}
+
+// Draw implements Layer.
+func (g Gradient) Draw(dst draw.Image) {
+	b := dst.Bounds()
+	steps := b.Dy()
+	if g.Horizontal {
+		steps = b.Dx()
+	}
+	for i := 0; i < steps; i++ {
+		var t float64
+		if steps > 1 {
+			t = float64(i) / float64(steps-1)
+		}
+		src := image.NewUniform(lerp(g.From, g.To, t))
+		r := image.Rect(b.Min.X, b.Min.Y+i, b.Max.X, b.Min.Y+i+1)
+		if g.Horizontal {
+			r = image.Rect(b.Min.X+i, b.Min.Y, b.Min.X+i+1, b.Max.Y)
+		}
+		draw.Draw(dst, r, src, image.Point{}, draw.Over)
+	}
+}
+
+func lerp(from color.Color, to color.Color, t float64) color.Color {
+	r0, g0, b0, a0 := from.RGBA()
+	r1, g1, b1, a1 := to.RGBA()
+	mix := func(a uint32, b uint32) uint16 {
+		return uint16(float64(a)*(1-t) + float64(b)*t + 0.5)
+	}
+	return color.RGBA64{R: mix(r0, r1), G: mix(g0, g1), B: mix(b0, b1), A: mix(a0, a1)}
+}
+
+// An Icon draws an image scaled to fit the key, less Padding pixels on each side, and centered.
+type Icon struct {
+	Image   image.Image
+	Padding int
+}
+
+// Draw implements Layer.
+func (i Icon) Draw(dst draw.Image) {
+	r := dst.Bounds().Inset(i.Padding)
+	if r.Empty() || i.Image == nil {
+		return
+	}
+	scaled := streamdeck.ScaleImage(i.Image, r.Dx(), r.Dy())
+	draw.Draw(dst, r, scaled, image.Point{}, draw.Over)
+}
+
+// A ProgressBar draws a horizontal bar along the bottom of the key, or the top if Top is set,
+// filled in proportion to Value, which ranges from 0 to 1.
+type ProgressBar struct {
+	Value      float64
+	Color      color.Color
+	Background color.Color
+	Height     int
+	Padding    int
+	Top        bool
+}
+
+// Draw implements Layer.
+func (p ProgressBar) Draw(dst draw.Image) {
+	b := dst.Bounds()
+	height := p.Height
+	if height <= 0 {
+		height = b.Dy() / 12
+	}
+	r := image.Rect(b.Min.X+p.Padding, b.Max.Y-p.Padding-height, b.Max.X-p.Padding, b.Max.Y-p.Padding)
+	if p.Top {
+		r = image.Rect(b.Min.X+p.Padding, b.Min.Y+p.Padding, b.Max.X-p.Padding, b.Min.Y+p.Padding+height)
+	}
+	if p.Background != nil {
+		draw.Draw(dst, r, image.NewUniform(p.Background), image.Point{}, draw.Over)
+	}
+
+	value := p.Value
+	if value < 0 {
+		value = 0
+	} else if value > 1 {
+		value = 1
+	}
+	filled := r
+	filled.Max.X = r.Min.X + int(float64(r.Dx())*value+0.5)
+	draw.Draw(dst, filled, image.NewUniform(p.Color), image.Point{}, draw.Over)
+}
@@ -0,0 +1,117 @@
+package keyimage
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	streamdeck "github.com/cliffrowley/go-streamdeck"
+	"golang.org/x/image/font"
+)
+
+func TestNewForDevice(t *testing.T) {
+	tests := []struct {
+		device *streamdeck.Device
+		want   int
+	}{
+		{device: nil, want: streamdeck.KeyImageSize2x},
+		{device: &streamdeck.Device{Type: streamdeck.StreamDeckPedal}, want: streamdeck.KeyImageSize2x},
+		{device: &streamdeck.Device{Type: streamdeck.StreamDeckPlus}, want: 240},
+	}
+	for _, test := range tests {
+		i := NewForDevice(test.device)
+		if i.Size != test.want {
+			t.Errorf("image for %v is %dpx, want %dpx", test.device, i.Size, test.want)
+		}
+		if _, err := i.Encode(); err != nil {
+			t.Errorf("encoding image for %v: %v", test.device, err)
+		}
+	}
+}
+
+// drawn returns the bounds of the pixels that are not transparent.
+func drawn(img *image.RGBA) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.RGBAAt(x, y).A != 0 {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+func TestTextFits(t *testing.T) {
+	const padding = 8
+	short := "Hi"
+	long := "A much longer label"
+
+	area := image.Rect(0, 0, 144, 144).Inset(padding)
+	shortSize := fitSize(Regular(), []string{short}, area, 144)
+	longSize := fitSize(Regular(), []string{long}, area, 144)
+	if longSize >= shortSize {
+		t.Errorf("long text fitted at %v, want smaller than short text at %v", longSize, shortSize)
+	}
+	face := newFace(Regular(), longSize)
+	defer face.Close()
+	if width := font.MeasureString(face, long).Ceil(); width > area.Dx() {
+		t.Errorf("long text is %dpx wide at size %v, want at most %dpx", width, longSize, area.Dx())
+	}
+
+	img := New(144).Add(Text{Text: long, Padding: padding}).Render()
+	if r := drawn(img); r.Empty() || !r.In(area) {
+		t.Errorf("text drawn within %v, want within %v", r, area)
+	}
+}
+
+func TestBadgeCorners(t *testing.T) {
+	tests := []struct {
+		corner int
+		want   image.Point
+	}{
+		{corner: TopRight, want: image.Pt(120, 24)},
+		{corner: TopLeft, want: image.Pt(24, 24)},
+		{corner: BottomRight, want: image.Pt(120, 120)},
+		{corner: BottomLeft, want: image.Pt(24, 120)},
+	}
+	for _, test := range tests {
+		img := New(144).Add(Badge{Corner: test.corner}).Render()
+		r := drawn(img)
+		center := r.Min.Add(r.Max).Div(2)
+		if d := center.Sub(test.want); d.X < -1 || d.X > 1 || d.Y < -1 || d.Y > 1 {
+			t.Errorf("badge in corner %d centered at %v, want %v", test.corner, center, test.want)
+		}
+		if r.Dx() > 48 || r.Dy() > 48 {
+			t.Errorf("badge in corner %d covers %v, want a diameter of at most 48px", test.corner, r)
+		}
+	}
+}
+
+func TestProgressBarFill(t *testing.T) {
+	fill := color.RGBA{G: 0xff, A: 0xff}
+	tests := []struct {
+		value float64
+		want  int
+	}{
+		{value: 0, want: 0},
+		{value: 0.5, want: 72},
+		{value: 1.5, want: 144},
+	}
+	for _, test := range tests {
+		img := New(144).Add(ProgressBar{Value: test.value, Color: fill, Height: 10}).Render()
+		var filled int
+		for x := 0; x < 144; x++ {
+			if img.RGBAAt(x, 143) == fill {
+				filled++
+			}
+		}
+		if filled != test.want {
+			t.Errorf("value %v filled %dpx, want %dpx", test.value, filled, test.want)
+		}
+		if img.RGBAAt(0, 133).A != 0 {
+			t.Errorf("value %v drew above the bar", test.value)
+		}
+	}
+}
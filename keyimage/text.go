@@ -0,0 +1,273 @@
+package keyimage
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Horizontal alignments of text.
+const (
+	AlignCenter = iota
+	AlignLeft
+	AlignRight
+)
+
+// Vertical alignments of text.
+const (
+	AlignMiddle = iota
+	AlignTop
+	AlignBottom
+)
+
+// Corners of the key, for badges.
+const (
+	TopRight = iota
+	TopLeft
+	BottomRight
+	BottomLeft
+)
+
+const minFontSize = 6
+
+var (
+	defaultFonts    sync.Once
+	regular, bold   *opentype.Font
+	defaultFontsErr error
+)
+
+// Regular returns the regular Go font, which is used by default.
+func Regular() *opentype.Font {
+	loadDefaultFonts()
+	return regular
+}
+
+// Bold returns the bold Go font.
+func Bold() *opentype.Font {
+	loadDefaultFonts()
+	return bold
+}
+
+func loadDefaultFonts() {
+	defaultFonts.Do(func() {
+		regular, defaultFontsErr = opentype.Parse(goregular.TTF)
+		if defaultFontsErr == nil {
+			bold, defaultFontsErr = opentype.Parse(gobold.TTF)
+		}
+		if defaultFontsErr != nil {
+			panic("keyimage: parsing Go fonts: " + defaultFontsErr.Error())
+		}
+	})
+}
+
+// A Text draws one or more lines of text, separated by newlines, within the key less Padding
+// pixels on each side.
+//
+// When Size is zero the text is drawn at the largest size, up to MaxSize, at which every line
+// fits.
+type Text struct {
+	Text    string
+	Color   color.Color
+	Font    *opentype.Font
+	Size    float64
+	MaxSize float64
+	Align   int
+	VAlign  int
+	Padding int
+}
+
+// Draw implements Layer.
+func (t Text) Draw(dst draw.Image) {
+	r := dst.Bounds().Inset(t.Padding)
+	if r.Empty() || t.Text == "" {
+		return
+	}
+	f := t.Font
+	if f == nil {
+		f = Regular()
+	}
+	c := t.Color
+	if c == nil {
+		c = color.White
+	}
+	lines := strings.Split(t.Text, "\n")
+
+	size := t.Size
+	if size <= 0 {
+		max := t.MaxSize
+		if max <= 0 {
+			max = float64(r.Dy())
+		}
+		size = fitSize(f, lines, r, max)
+	}
+	face := newFace(f, size)
+	defer face.Close()
+	drawLines(dst, face, lines, r, c, t.Align, t.VAlign)
+}
+
+func newFace(f *opentype.Font, size float64) font.Face {
+	face, err := opentype.NewFace(f, &opentype.FaceOptions{
+		Size:    size,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
+	if err != nil {
+		panic("keyimage: creating font face: " + err.Error())
+	}
+	return face
+}
+
+// fitSize returns the largest font size, no larger than max, at which every line fits within r.
+func fitSize(f *opentype.Font, lines []string, r image.Rectangle, max float64) float64 {
+	fits := func(size float64) bool {
+		face := newFace(f, size)
+		defer face.Close()
+		if face.Metrics().Height.Ceil()*len(lines) > r.Dy() {
+			return false
+		}
+		for _, l := range lines {
+			if font.MeasureString(face, l).Ceil() > r.Dx() {
+				return false
+			}
+		}
+		return true
+	}
+
+	lo, hi := float64(minFontSize), max
+	if hi <= lo || fits(hi) {
+		return hi
+	}
+	for hi-lo > 0.5 {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+func drawLines(dst draw.Image, face font.Face, lines []string, r image.Rectangle, c color.Color, align int, valign int) {
+	m := face.Metrics()
+	lineHeight := m.Height.Ceil()
+	total := lineHeight * len(lines)
+
+	top := r.Min.Y + (r.Dy()-total)/2
+	switch valign {
+	case AlignTop:
+		top = r.Min.Y
+	case AlignBottom:
+		top = r.Max.Y - total
+	}
+
+	d := &font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: face}
+	for i, l := range lines {
+		width := font.MeasureString(face, l).Ceil()
+		x := r.Min.X + (r.Dx()-width)/2
+		switch align {
+		case AlignLeft:
+			x = r.Min.X
+		case AlignRight:
+			x = r.Max.X - width
+		}
+		y := top + i*lineHeight + m.Ascent.Ceil()
+		d.Dot = fixed.P(x, y)
+		d.DrawString(l)
+	}
+}
+
+// A Badge draws a filled circle in a corner of the key, containing short text such as a count.
+// Its diameter defaults to a third of the key.
+type Badge struct {
+	Text      string
+	Color     color.Color
+	TextColor color.Color
+	Font      *opentype.Font
+	Corner    int
+	Diameter  int
+	Padding   int
+}
+
+// Draw implements Layer.
+func (b Badge) Draw(dst draw.Image) {
+	bounds := dst.Bounds()
+	d := b.Diameter
+	if d <= 0 {
+		d = bounds.Dx() / 3
+	}
+
+	x := bounds.Max.X - b.Padding - d
+	y := bounds.Min.Y + b.Padding
+	switch b.Corner {
+	case TopLeft:
+		x = bounds.Min.X + b.Padding
+	case BottomRight:
+		y = bounds.Max.Y - b.Padding - d
+	case BottomLeft:
+		x = bounds.Min.X + b.Padding
+		y = bounds.Max.Y - b.Padding - d
+	}
+	r := image.Rect(x, y, x+d, y+d)
+
+	c := b.Color
+	if c == nil {
+		c = color.RGBA{R: 0xe0, G: 0x20, B: 0x20, A: 0xff}
+	}
+	draw.DrawMask(dst, r, image.NewUniform(c), image.Point{}, &circle{d: d}, image.Point{}, draw.Over)
+
+	textColor := b.TextColor
+	if textColor == nil {
+		textColor = color.White
+	}
+	f := b.Font
+	if f == nil {
+		f = Bold()
+	}
+	Text{Text: b.Text, Color: textColor, Font: f, Padding: d / 5}.Draw(subImage(dst, r))
+}
+
+// circle is an anti-aliased circular mask of diameter d.
+type circle struct {
+	d int
+}
+
+func (c *circle) ColorModel() color.Model { return color.AlphaModel }
+
+func (c *circle) Bounds() image.Rectangle { return image.Rect(0, 0, c.d, c.d) }
+
+func (c *circle) At(x int, y int) color.Color {
+	radius := float64(c.d) / 2
+	dx, dy := float64(x)+0.5-radius, float64(y)+0.5-radius
+	dist := radius - math.Sqrt(dx*dx+dy*dy)
+	switch {
+	case dist >= 0.5:
+		return color.Alpha{A: 0xff}
+	case dist <= -0.5:
+		return color.Alpha{}
+	}
+	return color.Alpha{A: uint8((dist + 0.5) * 0xff)}
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// subImage returns the part of dst within r, which shares its pixels.
+func subImage(dst draw.Image, r image.Rectangle) draw.Image {
+	if s, ok := dst.(subImager); ok {
+		if d, ok := s.SubImage(r).(draw.Image); ok {
+			return d
+		}
+	}
+	return dst
+}
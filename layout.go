@@ -0,0 +1,168 @@
+package streamdeck
+
+import (
+	"sort"
+
+	"github.com/tidwall/gjson"
+)
+
+// Contains reports whether the coordinates are within the keys of the device.
+func (d *Device) Contains(c Coordinates) bool {
+	if d.Size == nil {
+		return false
+	}
+	return c.Column >= 0 && c.Column < d.Size.Columns && c.Row >= 0 && c.Row < d.Size.Rows
+}
+
+// Index returns the index of the key at the given coordinates, counting from zero across each row
+// in turn, and whether the coordinates are within the device.
+func (d *Device) Index(c Coordinates) (int, bool) {
+	if !d.Contains(c) {
+		return 0, false
+	}
+	return c.Row*d.Size.Columns + c.Column, true
+}
+
+// Coordinates returns the coordinates of the key with the given index, and whether the index is
+// within the device.
+func (d *Device) Coordinates(index int) (Coordinates, bool) {
+	if d.Size == nil || d.Size.Columns <= 0 || index < 0 || index >= d.Size.Columns*d.Size.Rows {
+		return Coordinates{}, false
+	}
+	return Coordinates{Column: index % d.Size.Columns, Row: index / d.Size.Columns}, true
+}
+
+// Keys returns the coordinates of every key of the device in row-major order.
+func (d *Device) Keys() []Coordinates {
+	if d.Size == nil {
+		return nil
+	}
+	keys := make([]Coordinates, 0, d.Size.Columns*d.Size.Rows)
+	for row := 0; row < d.Size.Rows; row++ {
+		for column := 0; column < d.Size.Columns; column++ {
+			keys = append(keys, Coordinates{Column: column, Row: row})
+		}
+	}
+	return keys
+}
+
+// Neighbors returns the coordinates of the keys above, below, left of and right of the given
+// coordinates that are within the device, in that order.
+func (d *Device) Neighbors(c Coordinates) []Coordinates {
+	var neighbors []Coordinates
+	for _, n := range []Coordinates{
+		{Column: c.Column, Row: c.Row - 1},
+		{Column: c.Column, Row: c.Row + 1},
+		{Column: c.Column - 1, Row: c.Row},
+		{Column: c.Column + 1, Row: c.Row},
+	} {
+		if d.Contains(n) {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+// IsEdge reports whether the key at the given coordinates is in the first or last row or column
+// of the device.
+func (d *Device) IsEdge(c Coordinates) bool {
+	if !d.Contains(c) {
+		return false
+	}
+	return c.Row == 0 || c.Column == 0 || c.Row == d.Size.Rows-1 || c.Column == d.Size.Columns-1
+}
+
+// A Placement is the position of one of the plugin's visible contexts on a device.
+type Placement struct {
+	Context     string
+	Action      string
+	Device      string
+	Controller  string
+	Coordinates Coordinates
+}
+
+func (p Placement) isKeypad() bool {
+	return p.Controller == "" || p.Controller == ControllerKeypad
+}
+
+// ContextAt returns the placement of the plugin's context on the key at the given coordinates of
+// a device, and whether there is one.
+func (c *Client) ContextAt(device string, coords Coordinates) (Placement, bool) {
+	c.placementsLock.Lock()
+	defer c.placementsLock.Unlock()
+	for _, p := range c.placements {
+		if p.Device == device && p.Coordinates == coords && p.isKeypad() {
+			return p, true
+		}
+	}
+	return Placement{}, false
+}
+
+// PlacementOf returns the placement of a context, and whether it is visible on a key or dial.
+// Contexts within multi-actions have no placement.
+func (c *Client) PlacementOf(context string) (Placement, bool) {
+	c.placementsLock.Lock()
+	defer c.placementsLock.Unlock()
+	p, ok := c.placements[context]
+	return p, ok
+}
+
+// Placements returns the placements of the plugin's contexts visible on the keys of a device, in
+// row-major order.
+func (c *Client) Placements(device string) []Placement {
+	c.placementsLock.Lock()
+	var placements []Placement
+	for _, p := range c.placements {
+		if p.Device == device && p.isKeypad() {
+			placements = append(placements, p)
+		}
+	}
+	c.placementsLock.Unlock()
+
+	sort.Slice(placements, func(i int, j int) bool {
+		a, b := placements[i].Coordinates, placements[j].Coordinates
+		return a.Row < b.Row || (a.Row == b.Row && a.Column < b.Column)
+	})
+	return placements
+}
+
+// placeContext records the placement of a context from a willAppear event.
+func (c *Client) placeContext(msg gjson.Result) {
+	payload := msg.Get("payload")
+	if payload.Get("isInMultiAction").Bool() || !payload.Get("coordinates").Exists() {
+		return
+	}
+	p := Placement{
+		Context:    msg.Get("context").String(),
+		Action:     msg.Get("action").String(),
+		Device:     msg.Get("device").String(),
+		Controller: payload.Get("controller").String(),
+		Coordinates: Coordinates{
+			Column: int(payload.Get("coordinates.column").Int()),
+			Row:    int(payload.Get("coordinates.row").Int()),
+		},
+	}
+
+	c.placementsLock.Lock()
+	defer c.placementsLock.Unlock()
+	if c.placements == nil {
+		c.placements = make(map[string]Placement)
+	}
+	c.placements[p.Context] = p
+}
+
+func (c *Client) unplaceContext(context string) {
+	c.placementsLock.Lock()
+	defer c.placementsLock.Unlock()
+	delete(c.placements, context)
+}
+
+func (c *Client) unplaceDevice(device string) {
+	c.placementsLock.Lock()
+	defer c.placementsLock.Unlock()
+	for context, p := range c.placements {
+		if p.Device == device {
+			delete(c.placements, context)
+		}
+	}
+}
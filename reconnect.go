@@ -0,0 +1,116 @@
+package streamdeck
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// ReconnectOptions configures automatic reconnection when the connection to the Stream Deck
+// software is lost.
+type ReconnectOptions struct {
+	// MinBackoff is the delay before the first attempt to reconnect, which doubles after each
+	// failed attempt. The default is 1 second.
+	MinBackoff time.Duration
+
+	// MaxBackoff is the maximum delay between attempts. The default is 30 seconds.
+	MaxBackoff time.Duration
+
+	// MaxAttempts is the number of attempts made before giving up, or zero to keep trying
+	// indefinitely.
+	MaxAttempts int
+}
+
+// SetReconnect enables automatic reconnection with the given options, or disables it if opts is
+// nil. Once reconnected the plugin is registered again, and a ReconnectedEvent is sent to the
+// ReconnectedHandler and to every Action that implements ReconnectedHandler, so that titles and
+// images can be restored. It must be called before the event loop is started.
+func (c *Client) SetReconnect(opts *ReconnectOptions) {
+	if opts == nil {
+		c.reconnect = nil
+		return
+	}
+	o := *opts
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = defaultMinBackoff
+	}
+	if o.MaxBackoff < o.MinBackoff {
+		o.MaxBackoff = defaultMaxBackoff
+		if o.MaxBackoff < o.MinBackoff {
+			o.MaxBackoff = o.MinBackoff
+		}
+	}
+	c.reconnect = &o
+}
+
+// redial reconnects with exponential backoff, returning the number of attempts made. It returns
+// errStopped if the client is stopped before reconnecting.
+func (c *Client) redial(ctx context.Context) (int, error) {
+	delay := c.reconnect.MinBackoff
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, ctx.Err()
+		case <-c.stop:
+			timer.Stop()
+			return attempt, errStopped
+		case <-timer.C:
+		}
+
+		err := c.dial()
+		if err == nil || err == errStopped {
+			return attempt, err
+		}
+		if c.reconnect.MaxAttempts > 0 && attempt >= c.reconnect.MaxAttempts {
+			return attempt, fmt.Errorf("reconnecting after %d attempts: %v", attempt, err)
+		}
+
+		delay *= 2
+		if delay > c.reconnect.MaxBackoff {
+			delay = c.reconnect.MaxBackoff
+		}
+	}
+}
+
+func (c *Client) notifyReconnected(attempts int) {
+	evt := &ReconnectedEvent{Attempts: attempts}
+	for _, h := range c.reconnectedHandlers() {
+		if err := c.callReconnected(h, evt); err != nil {
+			c.reportError(err)
+		}
+	}
+}
+
+func (c *Client) callReconnected(h ReconnectedHandler, evt *ReconnectedEvent) (err error) {
+	defer c.recoverPanic("reconnected", nil, &err)
+	h.Reconnected(evt)
+	return nil
+}
+
+func (c *Client) reconnectedHandlers() []ReconnectedHandler {
+	var hs []ReconnectedHandler
+	if c.reconnectedHandler != nil {
+		hs = append(hs, c.reconnectedHandler)
+	}
+
+	c.actionsLock.Lock()
+	defer c.actionsLock.Unlock()
+	for _, a := range c.actions {
+		if h, ok := a.(ReconnectedHandler); ok {
+			hs = append(hs, h)
+		}
+	}
+	for _, i := range c.instances {
+		if h, ok := i.action.(ReconnectedHandler); ok {
+			hs = append(hs, h)
+		}
+	}
+	return hs
+}
@@ -0,0 +1,56 @@
+package streamdeck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDialClosesPreviousConnection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.Close()
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c, old := newTestClient(t)
+	c.url = "ws" + strings.TrimPrefix(srv.URL, "http")
+	if err := c.dial(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.getConn().Close()
+
+	old.lock.Lock()
+	defer old.lock.Unlock()
+	if !old.closed {
+		t.Error("previous connection not closed")
+	}
+}
+
+func TestDialAfterStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		(&websocket.Upgrader{}).Upgrade(w, r, nil)
+	}))
+	defer srv.Close()
+
+	c, old := newTestClient(t)
+	c.url = "ws" + strings.TrimPrefix(srv.URL, "http")
+	c.Stop()
+	if err := c.dial(); err != errStopped {
+		t.Fatalf("got error %v, want %v", err, errStopped)
+	}
+	if c.getConn() != old {
+		t.Error("connection replaced after the client stopped")
+	}
+}
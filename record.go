@@ -0,0 +1,241 @@
+package streamdeck
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Directions of recorded messages.
+const (
+	RecordOptions = "options"
+	RecordIn      = "in"
+	RecordOut     = "out"
+)
+
+// A Record is a single message in a recorded session, as written by a Recorder in JSON Lines
+// format.
+type Record struct {
+	Time      time.Time       `json:"time"`
+	Direction string          `json:"direction"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// A Recorder records the messages exchanged between a Client and the Stream Deck software.
+type Recorder struct {
+	lock sync.Mutex
+	enc  *json.Encoder
+}
+
+// NewRecorder returns a Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+func (r *Recorder) write(direction string, data []byte) error {
+	rec := Record{Time: time.Now(), Direction: direction, Data: data}
+	if !json.Valid(data) {
+		// Keep the record readable even when the message is not valid JSON.
+		rec.Data, _ = json.Marshal(string(data))
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.enc.Encode(rec)
+}
+
+// SetRecorder records every event received and command sent by the client from now on, or stops
+// recording if r is nil. The client's options are recorded first so that the session can be
+// replayed with a Replayer. It may be called while the client is running.
+func (c *Client) SetRecorder(r *Recorder) error {
+	if r == nil {
+		c.recorder.Store(nil)
+		return nil
+	}
+	opts, err := json.Marshal(Options{
+		Info:          c.info,
+		PluginUUID:    c.pluginUUID,
+		RegisterEvent: c.registerEvent,
+	})
+	if err != nil {
+		return err
+	}
+	if err := r.write(RecordOptions, opts); err != nil {
+		return err
+	}
+	c.recorder.Store(r)
+	return nil
+}
+
+func (c *Client) record(direction string, data []byte) {
+	r := c.recorder.Load()
+	if r == nil {
+		return
+	}
+	if err := r.write(direction, data); err != nil {
+		c.reportError(fmt.Errorf("recording: %v", err))
+	}
+}
+
+// A Replayer feeds the events of a recorded session back through a Client, so that the session
+// can be reproduced without the Stream Deck software. Events are replayed with the gaps between
+// them in the recording, scaled by SetSpeed.
+type Replayer struct {
+	client   *Client
+	conn     *replayConn
+	recorded []json.RawMessage
+}
+
+// NewReplayer returns a Replayer for the session recorded in r. Handlers should be registered on
+// its Client before calling Run.
+func NewReplayer(r io.Reader) (*Replayer, error) {
+	var (
+		opts     *Options
+		events   []replayEvent
+		recorded []json.RawMessage
+		last     time.Time
+	)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("reading record: %v", err)
+		}
+		switch rec.Direction {
+		case RecordOptions:
+			opts = &Options{}
+			if err := json.Unmarshal(rec.Data, opts); err != nil {
+				return nil, fmt.Errorf("reading options: %v", err)
+			}
+		case RecordIn:
+			var gap time.Duration
+			if !last.IsZero() && rec.Time.After(last) {
+				gap = rec.Time.Sub(last)
+			}
+			last = rec.Time
+			events = append(events, replayEvent{data: rec.Data, gap: gap})
+		case RecordOut:
+			recorded = append(recorded, rec.Data)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading records: %v", err)
+	}
+	if opts == nil {
+		return nil, errors.New("reading records: no options recorded")
+	}
+
+	c, err := newClient(*opts)
+	if err != nil {
+		return nil, err
+	}
+	conn := &replayConn{events: events, speed: 1, closed: make(chan struct{})}
+	c.conn = conn
+
+	return &Replayer{client: c, conn: conn, recorded: recorded}, nil
+}
+
+// Client returns the client that events are replayed through.
+func (r *Replayer) Client() *Client {
+	return r.client
+}
+
+// SetSpeed sets how fast events are replayed relative to the recording: 2 replays them twice as
+// fast, and 0 replays them back to back without waiting. The default is 1.
+func (r *Replayer) SetSpeed(speed float64) {
+	r.conn.lock.Lock()
+	defer r.conn.lock.Unlock()
+	r.conn.speed = speed
+}
+
+// Run replays every recorded event through the client, in order, and returns once they have all
+// been handled.
+func (r *Replayer) Run() error {
+	return r.client.Run()
+}
+
+// Recorded returns the commands sent in the recorded session.
+func (r *Replayer) Recorded() []json.RawMessage {
+	return r.recorded
+}
+
+// Sent returns the commands sent by the client while replaying.
+func (r *Replayer) Sent() []json.RawMessage {
+	return r.conn.sentCommands()
+}
+
+// A replayEvent is a recorded event and the time since the one before it.
+type replayEvent struct {
+	data []byte
+	gap  time.Duration
+}
+
+// replayConn is a conn that reads recorded events and collects the commands written to it.
+type replayConn struct {
+	lock   sync.Mutex
+	events []replayEvent
+	speed  float64
+	sent   []json.RawMessage
+	closed chan struct{}
+}
+
+func (c *replayConn) ReadMessage() (int, []byte, error) {
+	c.lock.Lock()
+	if len(c.events) == 0 {
+		c.lock.Unlock()
+		return 0, nil, io.EOF
+	}
+	e := c.events[0]
+	c.events = c.events[1:]
+	speed := c.speed
+	c.lock.Unlock()
+
+	if speed > 0 && e.gap > 0 {
+		timer := time.NewTimer(time.Duration(float64(e.gap) / speed))
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-c.closed:
+			return 0, nil, io.EOF
+		}
+	}
+	return websocket.TextMessage, e.data, nil
+}
+
+func (c *replayConn) WriteMessage(messageType int, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if messageType == websocket.TextMessage {
+		c.sent = append(c.sent, append(json.RawMessage(nil), data...))
+	}
+	return nil
+}
+
+func (c *replayConn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.events = nil
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+func (c *replayConn) sentCommands() []json.RawMessage {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]json.RawMessage(nil), c.sent...)
+}
@@ -0,0 +1,75 @@
+package streamdeck
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testRecording(gap time.Duration, events int) string {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var b strings.Builder
+	fmt.Fprintf(&b, `{"time":%q,"direction":"options","data":{"Info":"{}"}}`+"\n", start.Format(time.RFC3339Nano))
+	for i := 0; i < events; i++ {
+		t := start.Add(time.Duration(i) * gap)
+		fmt.Fprintf(&b, `{"time":%q,"direction":"in","data":{"event":"keyDown","context":"ctx","payload":{}}}`+"\n", t.Format(time.RFC3339Nano))
+	}
+	return b.String()
+}
+
+func TestReplayerHonoursGaps(t *testing.T) {
+	tests := []struct {
+		speed    float64
+		min, max time.Duration
+	}{
+		{speed: 1, min: 200 * time.Millisecond, max: time.Second},
+		{speed: 4, min: 50 * time.Millisecond, max: 190 * time.Millisecond},
+		{speed: 0, min: 0, max: 40 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprint(test.speed), func(t *testing.T) {
+			r, err := NewReplayer(strings.NewReader(testRecording(100*time.Millisecond, 3)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.SetSpeed(test.speed)
+			var handled int
+			r.Client().HandleKeyDownFunc(func(*KeyDownEvent) { handled++ })
+
+			start := time.Now()
+			if err := r.Run(); err != nil {
+				t.Fatal(err)
+			}
+			if elapsed := time.Since(start); elapsed < test.min || elapsed > test.max {
+				t.Errorf("replayed in %v, want between %v and %v", elapsed, test.min, test.max)
+			}
+			if handled != 3 {
+				t.Errorf("handled %d events, want 3", handled)
+			}
+		})
+	}
+}
+
+func TestSetRecorderWhileRunning(t *testing.T) {
+	r, err := NewReplayer(strings.NewReader(testRecording(time.Millisecond, 50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := r.Client()
+	done := make(chan error)
+	go func() { done <- r.Run() }()
+
+	for i := 0; i < 10; i++ {
+		if err := c.SetRecorder(NewRecorder(&bytes.Buffer{})); err != nil {
+			t.Fatal(err)
+		}
+		c.SetRecorder(nil)
+		c.SetRecorder(NewRecorder(io.Discard))
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
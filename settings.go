@@ -0,0 +1,70 @@
+package streamdeck
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// A SettingsEvent is an event that carries the settings of a context.
+type SettingsEvent interface {
+	settings() json.RawMessage
+}
+
+func (e *DialDownEvent) settings() json.RawMessage                 { return e.Payload.Settings }
+func (e *DialRotateEvent) settings() json.RawMessage               { return e.Payload.Settings }
+func (e *DialUpEvent) settings() json.RawMessage                   { return e.Payload.Settings }
+func (e *DidReceiveSettingsEvent) settings() json.RawMessage       { return e.Payload.Settings }
+func (e *KeyDownEvent) settings() json.RawMessage                  { return e.Payload.Settings }
+func (e *KeyUpEvent) settings() json.RawMessage                    { return e.Payload.Settings }
+func (e *TitleParametersDidChangeEvent) settings() json.RawMessage { return e.Payload.Settings }
+func (e *TouchTapEvent) settings() json.RawMessage                 { return e.Payload.Settings }
+func (e *WillAppearEvent) settings() json.RawMessage               { return e.Payload.Settings }
+func (e *WillDisappearEvent) settings() json.RawMessage            { return e.Payload.Settings }
+
+// Settings decodes and saves the settings of contexts as values of type T, which must be
+// serializable as a JSON object.
+type Settings[T any] struct {
+	client   *Client
+	defaults json.RawMessage
+}
+
+// NewSettings returns a Settings bound to the client. Fields missing from the settings received
+// with an event are filled in from defaults.
+func NewSettings[T any](c *Client, defaults T) (*Settings[T], error) {
+	data, err := json.Marshal(defaults)
+	if err != nil {
+		return nil, fmt.Errorf("encoding default settings: %v", err)
+	}
+	return &Settings[T]{client: c, defaults: data}, nil
+}
+
+// Decode returns the settings carried by an event.
+func (s *Settings[T]) Decode(e SettingsEvent) (T, error) {
+	return s.DecodeRaw(e.settings())
+}
+
+// DecodeRaw returns the settings held in data, as received from the Stream Deck software.
+func (s *Settings[T]) DecodeRaw(data json.RawMessage) (T, error) {
+	var v T
+	// The defaults are decoded afresh each time so that maps and slices are never shared between
+	// values.
+	if err := json.Unmarshal(s.defaults, &v); err != nil {
+		return v, fmt.Errorf("decoding default settings: %v", err)
+	}
+	if len(data) == 0 {
+		return v, nil
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("decoding settings: %v", err)
+	}
+	return v, nil
+}
+
+// Save persists v as the settings for a context.
+func (s *Settings[T]) Save(context string, v T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding settings: %v", err)
+	}
+	return s.client.SetSettings(context, data)
+}
@@ -0,0 +1,486 @@
+// Package streamdecktest provides a fake Stream Deck software for testing plugins.
+//
+// A Server speaks the plugin side of the Stream Deck websocket protocol. Events such as keyDown
+// and willAppear can be injected into the plugin, and every command the plugin sends is recorded
+// so that tests can assert on it.
+package streamdecktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	streamdeck "github.com/cliffrowley/go-streamdeck"
+	"github.com/gorilla/websocket"
+)
+
+// Values passed to the plugin by Options.
+const (
+	PluginUUID    = "com.example.plugin.test"
+	RegisterEvent = "registerPlugin"
+)
+
+const defaultTimeout = time.Second
+
+// A Device is a device reported to the plugin when it connects.
+type Device struct {
+	ID      string
+	Name    string
+	Type    int
+	Columns int
+	Rows    int
+}
+
+// A Context identifies the context an injected event belongs to.
+type Context struct {
+	Action   string
+	Context  string
+	Device   string
+	Column   int
+	Row      int
+	Settings json.RawMessage
+}
+
+// A Command is a message sent by the plugin to the Stream Deck software.
+type Command struct {
+	Event   string          `json:"event"`
+	Context string          `json:"context"`
+	Action  string          `json:"action"`
+	Device  string          `json:"device"`
+	Payload json.RawMessage `json:"payload"`
+	Data    json.RawMessage `json:"-"`
+}
+
+// A Server is a fake Stream Deck software listening on a local port.
+type Server struct {
+	// Devices are reported to the plugin in the info passed by Options.
+	Devices []Device
+
+	// Timeout is how long the Wait and Expect methods wait for the plugin. The default is 1
+	// second.
+	Timeout time.Duration
+
+	srv *httptest.Server
+
+	lock       sync.Mutex
+	changed    chan struct{}
+	conn       *websocket.Conn
+	registered string
+	commands   []Command
+	next       int
+}
+
+// NewServer starts and returns a new Server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		Timeout: defaultTimeout,
+		changed: make(chan struct{}),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Close disconnects the plugin and shuts down the server.
+func (s *Server) Close() {
+	s.lock.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.lock.Unlock()
+	s.srv.Close()
+}
+
+// Disconnect drops the plugin's connection without a close handshake, as if the Stream Deck
+// software had crashed, while continuing to accept new connections.
+func (s *Server) Disconnect() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	s.registered = ""
+}
+
+// Port returns the port the server is listening on.
+func (s *Server) Port() int {
+	return s.srv.Listener.Addr().(*net.TCPAddr).Port
+}
+
+// Options returns the options the Stream Deck software would pass to the plugin on launch.
+func (s *Server) Options() streamdeck.Options {
+	info := map[string]interface{}{
+		"application": map[string]string{
+			"language": "en",
+			"platform": "mac",
+			"version":  "4.1.0",
+		},
+		"devices": s.deviceInfo(),
+	}
+	data, _ := json.Marshal(info)
+	return streamdeck.Options{
+		Port:          s.Port(),
+		PluginUUID:    PluginUUID,
+		RegisterEvent: RegisterEvent,
+		Info:          string(data),
+	}
+}
+
+func (s *Server) deviceInfo() []interface{} {
+	devices := make([]interface{}, 0, len(s.Devices))
+	for _, d := range s.Devices {
+		devices = append(devices, map[string]interface{}{
+			"id":   d.ID,
+			"name": d.Name,
+			"type": d.Type,
+			"size": map[string]int{"columns": d.Columns, "rows": d.Rows},
+		})
+	}
+	return devices
+}
+
+// Connect returns a new Client connected to the server, once it has registered.
+func (s *Server) Connect() (*streamdeck.Client, error) {
+	c, err := streamdeck.ConnectWithOptions(s.Options())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.WaitRegistered(); err != nil {
+		c.Stop()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.lock.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = ws
+	s.registered = ""
+	s.lock.Unlock()
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		s.record(data)
+	}
+}
+
+func (s *Server) record(data []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var msg struct {
+		Event string `json:"event"`
+		UUID  string `json:"uuid"`
+	}
+	json.Unmarshal(data, &msg)
+	if s.registered == "" && msg.Event == RegisterEvent {
+		s.registered = msg.UUID
+	} else {
+		cmd := Command{Data: data}
+		json.Unmarshal(data, &cmd)
+		s.commands = append(s.commands, cmd)
+	}
+
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// wait calls f with the lock held until it returns true or the timeout elapses.
+func (s *Server) wait(f func() bool) bool {
+	timer := time.NewTimer(s.Timeout)
+	defer timer.Stop()
+	for {
+		s.lock.Lock()
+		ok := f()
+		changed := s.changed
+		s.lock.Unlock()
+		if ok {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// WaitRegistered waits for the plugin to connect and register.
+func (s *Server) WaitRegistered() error {
+	var uuid string
+	registered := s.wait(func() bool {
+		uuid = s.registered
+		return uuid != ""
+	})
+	if !registered {
+		return fmt.Errorf("plugin did not register within %v", s.Timeout)
+	}
+	if uuid != PluginUUID {
+		return fmt.Errorf("plugin registered with UUID %q, want %q", uuid, PluginUUID)
+	}
+	return nil
+}
+
+// Commands returns every command the plugin has sent.
+func (s *Server) Commands() []Command {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// Reset forgets every command the plugin has sent.
+func (s *Server) Reset() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.commands = nil
+	s.next = 0
+}
+
+// WaitCommand waits for the plugin to send a command with the given event name and context. Only
+// commands sent after the last one returned are considered, so consecutive calls match commands
+// in the order they were sent. An empty context matches any context.
+func (s *Server) WaitCommand(event string, context string) (Command, error) {
+	var cmd Command
+	found := s.wait(func() bool {
+		for i := s.next; i < len(s.commands); i++ {
+			c := s.commands[i]
+			if c.Event == event && (context == "" || c.Context == context) {
+				cmd = c
+				s.next = i + 1
+				return true
+			}
+		}
+		return false
+	})
+	if !found {
+		return cmd, fmt.Errorf("no %q command for context %q within %v", event, context, s.Timeout)
+	}
+	return cmd, nil
+}
+
+// ExpectCommand is like WaitCommand but fails the test if no command is sent.
+func (s *Server) ExpectCommand(t testing.TB, event string, context string) Command {
+	t.Helper()
+	cmd, err := s.WaitCommand(event, context)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cmd
+}
+
+// ExpectTitle fails the test unless the plugin sets the title of a context to title.
+func (s *Server) ExpectTitle(t testing.TB, context string, title string) {
+	t.Helper()
+	var p struct {
+		Title string `json:"title"`
+	}
+	s.decodePayload(t, s.ExpectCommand(t, "setTitle", context), &p)
+	if p.Title != title {
+		t.Fatalf("setTitle for context %q: got title %q, want %q", context, p.Title, title)
+	}
+}
+
+// ExpectImage fails the test unless the plugin sets the image of a context to image.
+func (s *Server) ExpectImage(t testing.TB, context string, image string) {
+	t.Helper()
+	var p struct {
+		Image string `json:"image"`
+	}
+	s.decodePayload(t, s.ExpectCommand(t, "setImage", context), &p)
+	if p.Image != image {
+		t.Fatalf("setImage for context %q: got image %.40q, want %.40q", context, p.Image, image)
+	}
+}
+
+// ExpectState fails the test unless the plugin sets the state of a context to state.
+func (s *Server) ExpectState(t testing.TB, context string, state int) {
+	t.Helper()
+	var p struct {
+		State int `json:"state"`
+	}
+	s.decodePayload(t, s.ExpectCommand(t, "setState", context), &p)
+	if p.State != state {
+		t.Fatalf("setState for context %q: got state %d, want %d", context, p.State, state)
+	}
+}
+
+// ExpectNoCommand fails the test if the plugin sends a command with the given event name and
+// context before the timeout elapses.
+func (s *Server) ExpectNoCommand(t testing.TB, event string, context string) {
+	t.Helper()
+	if cmd, err := s.WaitCommand(event, context); err == nil {
+		t.Fatalf("unexpected %q command for context %q: %s", event, context, cmd.Data)
+	}
+}
+
+func (s *Server) decodePayload(t testing.TB, cmd Command, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(cmd.Payload, v); err != nil {
+		t.Fatalf("decoding %q payload: %v", cmd.Event, err)
+	}
+}
+
+// Send sends an event to the plugin, encoded as JSON.
+func (s *Server) Send(event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.SendRaw(data)
+}
+
+// SendRaw sends a raw message to the plugin.
+func (s *Server) SendRaw(data []byte) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn == nil {
+		return fmt.Errorf("plugin not connected")
+	}
+	return s.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (s *Server) sendContextEvent(event string, ctx Context, payload map[string]interface{}) error {
+	payload["coordinates"] = map[string]int{"column": ctx.Column, "row": ctx.Row}
+	if ctx.Settings != nil {
+		payload["settings"] = ctx.Settings
+	} else {
+		payload["settings"] = json.RawMessage("{}")
+	}
+	return s.Send(map[string]interface{}{
+		"event":   event,
+		"action":  ctx.Action,
+		"context": ctx.Context,
+		"device":  ctx.Device,
+		"payload": payload,
+	})
+}
+
+// WillAppear sends a willAppear event for a context.
+func (s *Server) WillAppear(ctx Context) error {
+	return s.sendContextEvent("willAppear", ctx, map[string]interface{}{
+		"controller":      streamdeck.ControllerKeypad,
+		"isInMultiAction": false,
+		"state":           0,
+	})
+}
+
+// WillDisappear sends a willDisappear event for a context.
+func (s *Server) WillDisappear(ctx Context) error {
+	return s.sendContextEvent("willDisappear", ctx, map[string]interface{}{
+		"controller":      streamdeck.ControllerKeypad,
+		"isInMultiAction": false,
+		"state":           0,
+	})
+}
+
+// KeyDown sends a keyDown event for a context.
+func (s *Server) KeyDown(ctx Context) error {
+	return s.sendContextEvent("keyDown", ctx, map[string]interface{}{
+		"isInMultiAction": false,
+		"state":           0,
+	})
+}
+
+// KeyUp sends a keyUp event for a context.
+func (s *Server) KeyUp(ctx Context) error {
+	return s.sendContextEvent("keyUp", ctx, map[string]interface{}{
+		"isInMultiAction": false,
+		"state":           0,
+	})
+}
+
+// DialDown sends a dialDown event for a context.
+func (s *Server) DialDown(ctx Context) error {
+	return s.sendContextEvent("dialDown", ctx, map[string]interface{}{
+		"controller": streamdeck.ControllerEncoder,
+	})
+}
+
+// DialUp sends a dialUp event for a context.
+func (s *Server) DialUp(ctx Context) error {
+	return s.sendContextEvent("dialUp", ctx, map[string]interface{}{
+		"controller": streamdeck.ControllerEncoder,
+	})
+}
+
+// DialRotate sends a dialRotate event for a context, rotated by ticks, which are negative for
+// counter-clockwise rotation.
+func (s *Server) DialRotate(ctx Context, ticks int, pressed bool) error {
+	return s.sendContextEvent("dialRotate", ctx, map[string]interface{}{
+		"controller": streamdeck.ControllerEncoder,
+		"ticks":      ticks,
+		"pressed":    pressed,
+	})
+}
+
+// TouchTap sends a touchTap event for a context, tapped at x and y on the touch strip.
+func (s *Server) TouchTap(ctx Context, x int, y int, hold bool) error {
+	return s.sendContextEvent("touchTap", ctx, map[string]interface{}{
+		"controller": streamdeck.ControllerEncoder,
+		"tapPos":     [2]int{x, y},
+		"hold":       hold,
+	})
+}
+
+// DidReceiveSettings sends a didReceiveSettings event for a context, carrying its settings.
+func (s *Server) DidReceiveSettings(ctx Context) error {
+	return s.sendContextEvent("didReceiveSettings", ctx, map[string]interface{}{
+		"isInMultiAction": false,
+	})
+}
+
+// DidReceiveGlobalSettings sends a didReceiveGlobalSettings event carrying settings.
+func (s *Server) DidReceiveGlobalSettings(settings json.RawMessage) error {
+	return s.Send(map[string]interface{}{
+		"event":   "didReceiveGlobalSettings",
+		"payload": map[string]interface{}{"settings": settings},
+	})
+}
+
+// SendToPlugin sends a sendToPlugin event for a context, as if from its property inspector.
+func (s *Server) SendToPlugin(ctx Context, payload json.RawMessage) error {
+	return s.Send(map[string]interface{}{
+		"event":   "sendToPlugin",
+		"action":  ctx.Action,
+		"context": ctx.Context,
+		"payload": payload,
+	})
+}
+
+// DeviceDidConnect sends a deviceDidConnect event for a device.
+func (s *Server) DeviceDidConnect(d Device) error {
+	return s.Send(map[string]interface{}{
+		"event":  "deviceDidConnect",
+		"device": d.ID,
+		"deviceInfo": map[string]interface{}{
+			"name": d.Name,
+			"type": d.Type,
+			"size": map[string]int{"columns": d.Columns, "rows": d.Rows},
+		},
+	})
+}
+
+// DeviceDidDisconnect sends a deviceDidDisconnect event for a device.
+func (s *Server) DeviceDidDisconnect(id string) error {
+	return s.Send(map[string]interface{}{
+		"event":  "deviceDidDisconnect",
+		"device": id,
+	})
+}
@@ -0,0 +1,270 @@
+package streamdecktest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	streamdeck "github.com/cliffrowley/go-streamdeck"
+	"github.com/cliffrowley/go-streamdeck/streamdecktest"
+)
+
+var key = streamdecktest.Context{Action: "com.example.plugin.test.action", Context: "ctx", Device: "dev"}
+
+// run connects a client to the server, lets setup register handlers and runs it until the test
+// ends.
+func run(t *testing.T, s *streamdecktest.Server, setup func(c *streamdeck.Client)) *streamdeck.Client {
+	t.Helper()
+	c, err := s.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.RunContext(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return c
+}
+
+func TestKeyDownSetsTitle(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	run(t, s, func(c *streamdeck.Client) {
+		c.HandleKeyDownFunc(func(e *streamdeck.KeyDownEvent) {
+			c.SetTitle(e.Context, "pressed", streamdeck.TargetBoth)
+		})
+	})
+
+	if err := s.KeyDown(key); err != nil {
+		t.Fatal(err)
+	}
+	s.ExpectTitle(t, "ctx", "pressed")
+}
+
+func TestDeviceNames(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	s.Devices = []streamdecktest.Device{{ID: "dev", Name: "Desk", Type: streamdeck.StreamDeckXL, Columns: 8, Rows: 4}}
+	connected := make(chan streamdeck.DeviceChange, 1)
+	c := run(t, s, func(c *streamdeck.Client) {
+		changes, _ := c.SubscribeDevices(1)
+		go func() {
+			for change := range changes {
+				if change.Kind == streamdeck.DeviceAdded && change.Device.ID == "plus" {
+					connected <- change
+				}
+			}
+		}()
+	})
+
+	if d := c.GetDevice("dev"); d == nil || d.Name != "Desk" {
+		t.Fatalf("got device %+v, want one named %q", d, "Desk")
+	}
+	plus := streamdecktest.Device{ID: "plus", Name: "Plus", Type: streamdeck.StreamDeckPlus, Columns: 4, Rows: 2}
+	if err := s.DeviceDidConnect(plus); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case change := <-connected:
+		if change.Device.Name != "Plus" {
+			t.Errorf("connected device named %q, want %q", change.Device.Name, "Plus")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("device connection not reported")
+	}
+}
+
+func TestDialAndTouchEvents(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	run(t, s, func(c *streamdeck.Client) {
+		c.HandleDialRotateFunc(func(e *streamdeck.DialRotateEvent) {
+			c.SetTitle(e.Context, fmt.Sprint("rotate ", e.Payload.Ticks, " ", e.Payload.Pressed), streamdeck.TargetBoth)
+		})
+		c.HandleDialDownFunc(func(e *streamdeck.DialDownEvent) {
+			c.SetTitle(e.Context, "down", streamdeck.TargetBoth)
+		})
+		c.HandleDialUpFunc(func(e *streamdeck.DialUpEvent) {
+			c.SetTitle(e.Context, "up", streamdeck.TargetBoth)
+		})
+		c.HandleTouchTapFunc(func(e *streamdeck.TouchTapEvent) {
+			c.SetTitle(e.Context, fmt.Sprint("tap ", e.Payload.TapPos, " ", e.Payload.Hold), streamdeck.TargetBoth)
+		})
+	})
+
+	s.DialRotate(key, -3, true)
+	s.ExpectTitle(t, "ctx", "rotate -3 true")
+	s.DialDown(key)
+	s.ExpectTitle(t, "ctx", "down")
+	s.DialUp(key)
+	s.ExpectTitle(t, "ctx", "up")
+	s.TouchTap(key, 10, 20, true)
+	s.ExpectTitle(t, "ctx", "tap [10 20] true")
+}
+
+func TestWorkerPoolPreservesContextOrder(t *testing.T) {
+	const contexts, events = 4, 50
+
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	var (
+		lock sync.Mutex
+		seen = make(map[string][]int)
+		wg   sync.WaitGroup
+	)
+	wg.Add(contexts * events)
+	run(t, s, func(c *streamdeck.Client) {
+		c.SetDispatchOptions(streamdeck.DispatchOptions{Workers: 3})
+		c.HandleKeyDownFunc(func(e *streamdeck.KeyDownEvent) {
+			var settings struct{ N int }
+			json.Unmarshal(e.Payload.Settings, &settings)
+			// Give other workers the chance to overtake this one.
+			time.Sleep(time.Duration(settings.N%3) * time.Millisecond)
+
+			lock.Lock()
+			seen[e.Context] = append(seen[e.Context], settings.N)
+			lock.Unlock()
+			wg.Done()
+		})
+	})
+
+	for i := 0; i < events; i++ {
+		for j := 0; j < contexts; j++ {
+			ctx := key
+			ctx.Context = fmt.Sprint("ctx", j)
+			ctx.Settings = json.RawMessage(fmt.Sprintf(`{"N":%d}`, i))
+			if err := s.KeyDown(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	wg.Wait()
+
+	lock.Lock()
+	defer lock.Unlock()
+	for ctx, ns := range seen {
+		for i, n := range ns {
+			if n != i {
+				t.Fatalf("context %s handled events in order %v", ctx, ns)
+			}
+		}
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	reconnected := make(chan int, 1)
+	run(t, s, func(c *streamdeck.Client) {
+		c.SetReconnect(&streamdeck.ReconnectOptions{MinBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+		c.HandleReconnectedFunc(func(e *streamdeck.ReconnectedEvent) {
+			reconnected <- e.Attempts
+		})
+		c.HandleKeyDownFunc(func(e *streamdeck.KeyDownEvent) {
+			c.SetTitle(e.Context, "pressed", streamdeck.TargetBoth)
+		})
+	})
+
+	s.Disconnect()
+	if err := s.WaitRegistered(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case attempts := <-reconnected:
+		if attempts < 1 {
+			t.Errorf("reconnected after %d attempts", attempts)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("reconnection not reported")
+	}
+
+	if err := s.KeyDown(key); err != nil {
+		t.Fatal(err)
+	}
+	s.ExpectTitle(t, "ctx", "pressed")
+}
+
+func TestStopWhileReconnecting(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	c, err := s.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reconnected := make(chan int, 1)
+	c.SetReconnect(&streamdeck.ReconnectOptions{MinBackoff: time.Second})
+	c.HandleReconnectedFunc(func(e *streamdeck.ReconnectedEvent) {
+		reconnected <- e.Attempts
+	})
+
+	errc := make(chan error)
+	go func() { errc <- c.Run() }()
+	s.Disconnect()
+	time.Sleep(50 * time.Millisecond)
+	c.Stop()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("got error %v, want nil", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("client did not stop during the reconnection backoff")
+	}
+	select {
+	case <-reconnected:
+		t.Error("reconnection reported after stopping")
+	default:
+	}
+	if err := s.WaitRegistered(); err == nil {
+		t.Error("plugin registered again after stopping")
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s := streamdecktest.NewServer()
+	t.Cleanup(s.Close)
+	c, err := s.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	handled := make(chan struct{})
+	c.HandleKeyDownFunc(func(e *streamdeck.KeyDownEvent) {
+		time.Sleep(50 * time.Millisecond)
+		close(handled)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error)
+	go func() { errc <- c.RunContext(ctx) }()
+	if err := s.KeyDown(key); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != context.Canceled {
+			t.Fatalf("got error %v, want %v", err, context.Canceled)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("client did not shut down")
+	}
+	select {
+	case <-handled:
+	default:
+		t.Fatal("client shut down before the running handler returned")
+	}
+}
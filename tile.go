@@ -0,0 +1,71 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+)
+
+// A Region is a rectangular block of keys on a device, across which a single image can be shown
+// with SetTiledImage.
+type Region struct {
+	Device  string
+	Origin  Coordinates
+	Columns int
+	Rows    int
+
+	// Spacing is the gap between adjacent keys as a fraction of the key size, and must not be
+	// negative. The parts of the image that fall within the gaps are not shown, so that it
+	// appears continuous across the keys.
+	Spacing float64
+}
+
+// Contains reports whether the coordinates are within the region.
+func (r Region) Contains(c Coordinates) bool {
+	return c.Column >= r.Origin.Column && c.Column < r.Origin.Column+r.Columns &&
+		c.Row >= r.Origin.Row && c.Row < r.Origin.Row+r.Rows
+}
+
+// SetTiledImage shows a single image across the keys of a region, scaled to fit it while
+// preserving its aspect ratio. Each of the plugin's contexts within the region is sent the tile
+// of the image for its key; keys without one are left untouched.
+func (c *Client) SetTiledImage(region Region, img image.Image, target Target) error {
+	d := c.GetDevice(region.Device)
+	if d == nil {
+		return fmt.Errorf("tiling image: unknown device %q", region.Device)
+	}
+	last := Coordinates{
+		Column: region.Origin.Column + region.Columns - 1,
+		Row:    region.Origin.Row + region.Rows - 1,
+	}
+	if region.Columns <= 0 || region.Rows <= 0 || !d.Contains(region.Origin) || !d.Contains(last) {
+		return fmt.Errorf("tiling image: region %+v is not within device %q", region, region.Device)
+	}
+	if region.Spacing < 0 {
+		return fmt.Errorf("tiling image: negative spacing %v", region.Spacing)
+	}
+
+	size := KeyImageSize2x
+	if d.KeySize() > 0 {
+		size = 2 * d.KeySize()
+	}
+	gap := int(math.Round(region.Spacing * float64(size)))
+	canvas := ScaleImage(img, region.Columns*size+(region.Columns-1)*gap, region.Rows*size+(region.Rows-1)*gap)
+
+	var err error
+	for _, p := range c.Placements(region.Device) {
+		if !region.Contains(p.Coordinates) {
+			continue
+		}
+		x := (p.Coordinates.Column - region.Origin.Column) * (size + gap)
+		y := (p.Coordinates.Row - region.Origin.Row) * (size + gap)
+		tile := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.Draw(tile, tile.Bounds(), canvas, image.Pt(x, y), draw.Src)
+
+		if serr := c.SetImageFromImage(p.Context, tile, target); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
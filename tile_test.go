@@ -0,0 +1,63 @@
+package streamdeck
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestSetTiledImage(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.devices["dev"] = &Device{ID: "dev", Type: StreamDeck, Size: &Size{Columns: 5, Rows: 3}}
+	for _, p := range []Coordinates{{Column: 0, Row: 0}, {Column: 1, Row: 1}, {Column: 2, Row: 1}, {Column: 1, Row: 2}, {Column: 2, Row: 2}} {
+		c.dispatch([]byte(fmt.Sprintf(`{"event":"willAppear","action":"action","context":"%d,%d","device":"dev",`+
+			`"payload":{"controller":"Keypad","coordinates":{"column":%d,"row":%d},"settings":{}}}`,
+			p.Column, p.Row, p.Column, p.Row)))
+	}
+
+	// Keys are 144px with a 36px gap, so the image is exactly the size of the 2x2 region and each
+	// pixel records its own position.
+	src := image.NewRGBA(image.Rect(0, 0, 324, 324))
+	for y := 0; y < 324; y++ {
+		for x := 0; x < 324; x++ {
+			src.SetRGBA(x, y, color.RGBA{R: uint8(x / 2), G: uint8(y / 2), B: 0xff, A: 0xff})
+		}
+	}
+	region := Region{Device: "dev", Origin: Coordinates{Column: 1, Row: 1}, Columns: 2, Rows: 2, Spacing: 0.25}
+	if err := c.SetTiledImage(region, src, TargetBoth); err != nil {
+		t.Fatal(err)
+	}
+
+	written := conn.take()
+	if len(written) != 4 {
+		t.Fatalf("sent %d images, want 4", len(written))
+	}
+	offsets := map[string]image.Point{"1,1": {0, 0}, "2,1": {180, 0}, "1,2": {0, 180}, "2,2": {180, 180}}
+	for _, msg := range written {
+		context := gjson.Get(msg, "context").String()
+		offset, ok := offsets[context]
+		if !ok {
+			t.Errorf("sent image to context %q outside the region", context)
+			continue
+		}
+		tile := decodeDataURI(t, gjson.Get(msg, "payload.image").String())
+		for _, p := range []image.Point{{0, 0}, {143, 0}, {0, 143}, {143, 143}} {
+			got := color.RGBAModel.Convert(tile.At(p.X, p.Y))
+			if want := src.RGBAAt(offset.X+p.X, offset.Y+p.Y); got != want {
+				t.Errorf("context %s pixel %v is %v, want %v from %v", context, p, got, want, offset.Add(p))
+			}
+		}
+	}
+}
+
+func TestSetTiledImageNegativeSpacing(t *testing.T) {
+	c, _ := newTestClient(t)
+	c.devices["dev"] = &Device{ID: "dev", Type: StreamDeck, Size: &Size{Columns: 5, Rows: 3}}
+	region := Region{Device: "dev", Columns: 2, Rows: 2, Spacing: -0.1}
+	if err := c.SetTiledImage(region, image.NewRGBA(image.Rect(0, 0, 10, 10)), TargetBoth); err == nil {
+		t.Error("tiled an image with negative spacing")
+	}
+}
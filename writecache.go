@@ -0,0 +1,169 @@
+package streamdeck
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// WriteCacheOptions configures the cache of setTitle, setImage and setState commands.
+type WriteCacheOptions struct {
+	// Window is the period over which bursts of commands for the same context and target are
+	// coalesced. The first command is sent immediately and only the latest of any that follow
+	// within the window is sent when it ends. When zero, commands are only de-duplicated.
+	Window time.Duration
+}
+
+// SetWriteCache enables caching of the setTitle, setImage and setState commands sent for each
+// context and target, or disables it if opts is nil. Commands identical to the last one sent are
+// suppressed. The cache for a context is invalidated when it appears or its title parameters
+// change, its state is invalidated when its key is pressed or released, and the whole cache is
+// invalidated on reconnecting, so that the next command is always sent.
+func (c *Client) SetWriteCache(opts *WriteCacheOptions) {
+	c.writeCacheLock.Lock()
+	defer c.writeCacheLock.Unlock()
+	if opts == nil {
+		c.writeCache = nil
+		return
+	}
+	c.writeCache = &writeCache{
+		window:  opts.Window,
+		sent:    make(map[cacheKey][]byte),
+		pending: make(map[cacheKey][]byte),
+		timers:  make(map[cacheKey]*time.Timer),
+	}
+}
+
+type cacheKey struct {
+	event   string
+	context string
+	target  Target
+	state   int
+}
+
+type writeCache struct {
+	lock    sync.Mutex
+	window  time.Duration
+	sent    map[cacheKey][]byte
+	pending map[cacheKey][]byte
+	timers  map[cacheKey]*time.Timer
+}
+
+// sendCached sends a command through the write cache, if enabled. A state of -1 means the command
+// applies to every state.
+func (c *Client) sendCached(event string, context string, target Target, state int, cmd interface{}) error {
+	c.writeCacheLock.Lock()
+	w := c.writeCache
+	c.writeCacheLock.Unlock()
+	if w == nil {
+		return c.sendCommand(cmd)
+	}
+
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return err
+	}
+	key := cacheKey{event: event, context: context, target: target, state: state}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.timers[key]; ok {
+		w.pending[key] = data
+		return nil
+	}
+	if err := w.sendLocked(c, key, data); err != nil {
+		return err
+	}
+	if w.window > 0 {
+		w.timers[key] = time.AfterFunc(w.window, func() { c.flushCached(w, key) })
+	}
+	return nil
+}
+
+func (w *writeCache) sendLocked(c *Client, key cacheKey, data []byte) error {
+	if bytes.Equal(w.sent[key], data) {
+		return nil
+	}
+	if err := c.send(data); err != nil {
+		return err
+	}
+
+	// A title or image sent for one target or state may replace what is shown for another, so
+	// only the latest one for the context is known to be displayed.
+	for k := range w.sent {
+		if k.event == key.event && k.context == key.context {
+			delete(w.sent, k)
+		}
+	}
+	w.sent[key] = data
+	return nil
+}
+
+// flushCached sends the latest command held for a key at the end of its window, starting a new
+// window if there was one.
+func (c *Client) flushCached(w *writeCache, key cacheKey) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	data, ok := w.pending[key]
+	if !ok {
+		delete(w.timers, key)
+		return
+	}
+	delete(w.pending, key)
+	w.timers[key] = time.AfterFunc(w.window, func() { c.flushCached(w, key) })
+	if err := w.sendLocked(c, key, data); err != nil {
+		c.reportError(err)
+	}
+}
+
+// invalidateCache forgets the commands last sent for a context, so that the next ones are sent
+// even if identical.
+func (c *Client) invalidateCache(context string) {
+	c.forgetCached(func(key cacheKey) bool { return key.context == context }, false)
+}
+
+// invalidateCachedState forgets the state last set for a context, which the Stream Deck software
+// changes itself when a key with multiple states is pressed.
+func (c *Client) invalidateCachedState(context string) {
+	c.forgetCached(func(key cacheKey) bool { return key.event == "setState" && key.context == context }, false)
+}
+
+// resetCache forgets every command last sent, for when the Stream Deck software may have lost
+// them.
+func (c *Client) resetCache() {
+	c.forgetCached(func(key cacheKey) bool { return true }, false)
+}
+
+// discardCache drops everything cached for a context that has disappeared, including commands
+// still waiting to be sent.
+func (c *Client) discardCache(context string) {
+	c.forgetCached(func(key cacheKey) bool { return key.context == context }, true)
+}
+
+func (c *Client) forgetCached(match func(cacheKey) bool, pending bool) {
+	c.writeCacheLock.Lock()
+	w := c.writeCache
+	c.writeCacheLock.Unlock()
+	if w == nil {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	for key := range w.sent {
+		if match(key) {
+			delete(w.sent, key)
+		}
+	}
+	if !pending {
+		return
+	}
+	for key, t := range w.timers {
+		if match(key) {
+			t.Stop()
+			delete(w.timers, key)
+			delete(w.pending, key)
+		}
+	}
+}
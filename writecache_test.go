@@ -0,0 +1,160 @@
+package streamdeck
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// testConn is a conn that collects the messages written to it and can be made to fail writes.
+type testConn struct {
+	lock    sync.Mutex
+	written []string
+	err     error
+	closed  bool
+}
+
+func (c *testConn) ReadMessage() (int, []byte, error) {
+	return 0, nil, io.EOF
+}
+
+func (c *testConn) WriteMessage(messageType int, data []byte) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.written = append(c.written, string(data))
+	return nil
+}
+
+func (c *testConn) Close() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *testConn) fail(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.err = err
+}
+
+// take returns the messages written since it was last called.
+func (c *testConn) take() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	written := c.written
+	c.written = nil
+	return written
+}
+
+func newTestClient(t *testing.T) (*Client, *testConn) {
+	t.Helper()
+	c, err := newClient(Options{Info: "{}"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := &testConn{}
+	c.conn = conn
+	return c, conn
+}
+
+func TestWriteCacheSuppressesDuplicates(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{})
+
+	c.SetTitle("ctx", "hello", TargetBoth)
+	c.SetTitle("ctx", "hello", TargetBoth)
+	if got := len(conn.take()); got != 1 {
+		t.Fatalf("sent %d commands, want 1", got)
+	}
+
+	c.dispatch([]byte(`{"event":"titleParametersDidChange","context":"ctx"}`))
+	c.SetTitle("ctx", "hello", TargetBoth)
+	if got := len(conn.take()); got != 1 {
+		t.Fatalf("sent %d commands after invalidation, want 1", got)
+	}
+}
+
+func TestWriteCacheRetriesFailedSend(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{Window: time.Hour})
+
+	conn.fail(errors.New("broken pipe"))
+	if err := c.SetTitle("ctx", "hello", TargetBoth); err == nil {
+		t.Fatal("SetTitle succeeded on a broken connection")
+	}
+	conn.fail(nil)
+	if err := c.SetTitle("ctx", "hello", TargetBoth); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(conn.take()); got != 1 {
+		t.Fatalf("sent %d commands on retry, want 1", got)
+	}
+}
+
+func TestWriteCacheOtherTarget(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{})
+
+	c.SetTitle("ctx", "A", TargetBoth)
+	c.SetTitle("ctx", "B", TargetHardware)
+	c.SetTitle("ctx", "A", TargetBoth)
+	written := conn.take()
+	if len(written) != 3 {
+		t.Fatalf("sent %d commands, want 3", len(written))
+	}
+	if got := gjson.Get(written[2], "payload.title").String(); got != "A" {
+		t.Fatalf("last title sent is %q, want %q", got, "A")
+	}
+}
+
+func TestWriteCacheStateAfterKeyUp(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{})
+
+	c.SetState("ctx", 0)
+	c.dispatch([]byte(`{"event":"keyUp","context":"ctx","payload":{"state":1}}`))
+	c.SetState("ctx", 0)
+	if got := len(conn.take()); got != 2 {
+		t.Fatalf("sent %d commands, want 2", got)
+	}
+}
+
+func TestWriteCacheReset(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{})
+
+	c.SetImage("ctx", "data:image/png;base64,AAAA", TargetBoth)
+	c.resetCache()
+	c.SetImage("ctx", "data:image/png;base64,AAAA", TargetBoth)
+	if got := len(conn.take()); got != 2 {
+		t.Fatalf("sent %d commands, want 2", got)
+	}
+}
+
+func TestWriteCacheDiscardsDisappearedContext(t *testing.T) {
+	c, conn := newTestClient(t)
+	c.SetWriteCache(&WriteCacheOptions{Window: 20 * time.Millisecond})
+
+	c.SetTitle("ctx", "one", TargetBoth)
+	c.SetTitle("ctx", "two", TargetBoth)
+	c.dispatch([]byte(`{"event":"willDisappear","context":"ctx","payload":{}}`))
+	time.Sleep(50 * time.Millisecond)
+	if got := len(conn.take()); got != 1 {
+		t.Fatalf("sent %d commands, want 1", got)
+	}
+
+	w := c.writeCache
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.sent) != 0 || len(w.pending) != 0 || len(w.timers) != 0 {
+		t.Fatalf("cache holds %d sent, %d pending and %d timers, want none", len(w.sent), len(w.pending), len(w.timers))
+	}
+}